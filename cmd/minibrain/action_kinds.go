@@ -8,13 +8,25 @@ const (
 	ActionReadApproved    ActionKind = "READ APPROVED"
 	ActionReadDenied      ActionKind = "READ DENIED"
 	ActionReadAlways      ActionKind = "READ ALWAYS APPROVED"
+	ActionReadNever       ActionKind = "READ NEVER APPROVED"
 	ActionWrite           ActionKind = "WRITE"
+	ActionWriteFailed     ActionKind = "WRITE FAILED"
 	ActionDelete          ActionKind = "DELETE"
+	ActionDeleteFailed    ActionKind = "DELETE FAILED"
 	ActionPatch           ActionKind = "PATCH"
 	ActionPatchFailed     ActionKind = "PATCH FAILED"
+	ActionEdit            ActionKind = "EDIT"
+	ActionEditFailed      ActionKind = "EDIT FAILED"
 	ActionChangesBlocked  ActionKind = "CHANGES BLOCKED"
+	ActionChangesRollback ActionKind = "CHANGES ROLLED BACK"
+	ActionConflict        ActionKind = "CONFLICT"
 	ActionChangesDenied   ActionKind = "CHANGES DENIED"
 	ActionChangesAuto     ActionKind = "CHANGES AUTO-APPLY ENABLED"
+	ActionCheckpoint      ActionKind = "CHECKPOINT"
+	ActionReview          ActionKind = "REVIEW"
+	ActionGit             ActionKind = "GIT"
+	ActionSession         ActionKind = "SESSION"
+	ActionExport          ActionKind = "EXPORT"
 	ActionError           ActionKind = "ERROR"
 	ActionModel           ActionKind = "MODEL"
 	ActionMemory          ActionKind = "MEMORY"
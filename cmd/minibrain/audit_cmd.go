@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+// auditInfo attributes audit entries to the active session and model.
+func auditInfo(approval string) agent.AuditInfo {
+	return agent.AuditInfo{Session: activeSessionID, Model: currentModel(), Approval: approval}
+}
+
+func recordWritePermission(m *tuiModel, command, decision string) {
+	root, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	if err := agent.RecordPermission(root, auditInfo(command), "write", "", decision); err != nil {
+		m.appendAction(formatAction(ActionError, "audit log: "+err.Error()))
+	}
+}
+
+// parseSince reads a duration back from now ("24h", "7d") or a date or
+// timestamp ("2025-01-31", RFC 3339).
+func parseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 24h or 7d, or a date", s)
+}
+
+// runAuditCLI handles `minibrain audit [--since] [--path] [--json]`.
+func runAuditCLI(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	since := fs.String("since", "", "only entries newer than a duration (24h, 7d) or date")
+	path := fs.String("path", "", "only entries for this path or glob")
+	asJSON := fs.Bool("json", false, "print entries as JSON lines")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return errors.New("usage: minibrain audit [--since 24h|7d|2025-01-31] [--path <path|glob>] [--json]")
+	}
+	filter := agent.AuditFilter{Path: *path}
+	if *since != "" {
+		t, err := parseSince(*since, time.Now())
+		if err != nil {
+			return err
+		}
+		filter.Since = t
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	entries, err := agent.LoadAudit(root, filter)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 && !*asJSON {
+		fmt.Println("no audit entries")
+		return nil
+	}
+	for _, e := range entries {
+		if *asJSON {
+			b, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			continue
+		}
+		fmt.Println(formatAuditEntry(e))
+	}
+	return nil
+}
+
+func formatAuditEntry(e agent.AuditEntry) string {
+	parts := []string{e.Time, e.Action}
+	if e.Path != "" {
+		parts = append(parts, e.Path)
+	}
+	if e.Decision != "" {
+		parts = append(parts, e.Decision)
+	}
+	if e.Status != "" {
+		parts = append(parts, e.Status)
+	}
+	if e.Action == agent.AuditRead && e.HashBefore != "" {
+		parts = append(parts, fmt.Sprintf("%dB %s", e.BytesBefore, shortHash(e.HashBefore)))
+	} else if e.HashBefore != "" || e.HashAfter != "" {
+		parts = append(parts, fmt.Sprintf("%dB %s -> %dB %s", e.BytesBefore, shortHash(e.HashBefore), e.BytesAfter, shortHash(e.HashAfter)))
+	}
+	for _, kv := range [][2]string{{"approval", e.Approval}, {"session", e.Session}, {"model", e.Model}, {"reason", e.Reason}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+kv[1])
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+func shortHash(h string) string {
+	if h == "" {
+		return "-"
+	}
+	return h[:min(12, len(h))]
+}
@@ -10,28 +10,34 @@ import (
 )
 
 type configOptions struct {
-	allowRead  bool
-	allowWrite bool
-	readPaths  []string
+	allowRead     bool
+	allowWrite    bool
+	readPaths     []string
+	approvals     agent.ReadApprovals
+	readApproval  string
+	writeApproval string
 }
 
 func buildConfig(root, brainDir string, opts configOptions) agent.Config {
+	user, _ := userconfig.Load()
+	// Run refuses to start on a project config that does not parse.
+	project, _ := agent.LoadProjectConfig(root)
 	model := strings.TrimSpace(os.Getenv("OPENAI_MODEL"))
 	if model == "" {
-		if cfg, err := userconfig.Load(); err == nil {
-			model = strings.TrimSpace(cfg.Model)
-		}
+		model = strings.TrimSpace(user.Model)
 	}
-	return agent.Config{
+	cfg := agent.Config{
 		RootDir:             root,
 		BrainDir:            brainDir,
 		Model:               model,
 		TimeoutSec:          60,
 		NeoPath:             "",
+		ProjectNeoPath:      agent.ProjectNeoPath(root),
 		PrefrontalPath:      "",
-		StmMaxBytes:         12000,
-		StmContextBytes:     4000,
-		ConversationBytes:   4000,
+		StmMaxTokens:        3000,
+		StmKeepTurns:        3,
+		StmContextTokens:    1000,
+		ConversationTokens:  1000,
 		ContextBudgetTokens: 16000,
 		ApplyWrites:         opts.allowWrite,
 		ReadPaths:           opts.readPaths,
@@ -39,6 +45,33 @@ func buildConfig(root, brainDir string, opts configOptions) agent.Config {
 		MaxFileBytes:        512 * 1024,
 		MaxTotalReadBytes:   2 * 1024 * 1024,
 		AllowReadAll:        opts.allowRead,
+		ReadApprovals:       opts.approvals,
+		ReadApproval:        opts.readApproval,
+		WriteApproval:       opts.writeApproval,
+		AutoPromote:         user.AutoPromote || autoPromoteFromEnv(),
+		MemoryTopK:          12,
+		RedactPatterns:      append(user.RedactPatterns, project.RedactPatterns...),
+		Git:                 agent.ResolveGitOptions(root, gitEnabledFromEnv()),
+	}
+	if s, ok := existingSession(root, brainDir); ok {
+		cfg.PrefrontalPath = s.PrefrontalPath(brainDir)
+		cfg.ContextPath = s.ContextPath(brainDir)
+		cfg.SessionID = s.ID
+	}
+	return cfg
+}
+
+// applyConfig is the part of the config that applying changes and /commit
+// use: the model for commit messages, the redaction patterns for the diffs
+// sent to it or kept in the transcript, and the git options.
+func applyConfig(root string) agent.Config {
+	user, _ := userconfig.Load()
+	project, _ := agent.LoadProjectConfig(root)
+	return agent.Config{
+		RootDir:        root,
+		Model:          currentModel(),
+		RedactPatterns: append(user.RedactPatterns, project.RedactPatterns...),
+		Git:            agent.ResolveGitOptions(root, gitEnabledFromEnv()),
 	}
 }
 
@@ -57,8 +90,10 @@ func baseConfig() (agent.Config, error) {
 
 	perms := agent.ResolvePermissionState(root, readAllowedFromEnv(), writeAllowedFromEnv())
 	cfg := buildConfig(root, brainDir, configOptions{
-		allowRead:  perms.AllowRead,
-		allowWrite: perms.AllowWrite,
+		allowRead:     perms.AllowRead,
+		allowWrite:    perms.AllowWrite,
+		readApproval:  perms.ReadSource,
+		writeApproval: perms.WriteSource,
 	})
 	return cfg, nil
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+// parseExportArgs reads `[md|html|json] [path]`. A path with a known
+// extension also sets the format.
+func parseExportArgs(args []string) (format, path string, err error) {
+	if len(args) > 0 {
+		if f, ok := agent.ParseExportFormat(args[0]); ok {
+			format, args = f, args[1:]
+		}
+	}
+	if len(args) > 1 {
+		return "", "", errors.New("usage: [md|html|json] [path]")
+	}
+	if len(args) == 1 {
+		path = args[0]
+		if f, ok := agent.ParseExportFormat(filepath.Ext(path)); ok && format == "" {
+			format = f
+		}
+	}
+	if format == "" {
+		format = "md"
+	}
+	return format, path, nil
+}
+
+// writeExport renders the session and writes it to path, or to the default
+// file name in the working directory. A path of "-" prints it instead.
+func writeExport(cfg agent.Config, s agent.Session, opts agent.ExportOptions, path string) (string, error) {
+	out, err := agent.ExportSession(cfg, s, opts)
+	if err != nil {
+		return "", err
+	}
+	if path == "-" {
+		fmt.Print(out)
+		return "stdout", nil
+	}
+	if path == "" {
+		path = agent.DefaultExportPath(s, opts.Format)
+	}
+	if err := os.WriteFile(path, []byte(out), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// runExportCLI handles `minibrain export [session] [md|html|json] [path]`.
+func runExportCLI(args []string) error {
+	cfg, err := baseConfig()
+	if err != nil {
+		return err
+	}
+	id := cfg.SessionID
+	if len(args) > 0 {
+		if _, ok := agent.ParseExportFormat(args[0]); !ok {
+			id, args = args[0], args[1:]
+		}
+	}
+	if id == "" {
+		return errors.New("no session to export yet")
+	}
+	s, err := agent.LoadSession(cfg.BrainDir, id)
+	if err != nil {
+		return err
+	}
+	format, path, err := parseExportArgs(args)
+	if err != nil {
+		return errors.New("usage: minibrain export [session] [md|html|json] [path]")
+	}
+	written, err := writeExport(cfg, s, agent.ExportOptions{Format: format}, path)
+	if err != nil {
+		return err
+	}
+	if written != "stdout" {
+		fmt.Println("exported", s.ID, "to", written)
+	}
+	return nil
+}
+
+func handleExportCommand(m *tuiModel, prompt string) tea.Cmd {
+	cfg, err := baseConfig()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	format, path, err := parseExportArgs(strings.Fields(prompt)[1:])
+	if err != nil || path == "-" {
+		m.appendAction(formatAction(ActionInfo, "Usage: /export [md|html|json] [path]"))
+		return nil
+	}
+	if cfg.SessionID == "" {
+		m.appendAction(formatAction(ActionInfo, "No session to export yet."))
+		return nil
+	}
+	s, err := agent.LoadSession(cfg.BrainDir, cfg.SessionID)
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	opts := agent.ExportOptions{Format: format, Refs: m.pendingRefs}
+	if m.res != nil {
+		opts.Refs = agent.MergeFileRefs(opts.Refs, m.res.FileRefs)
+	}
+	for _, h := range m.history {
+		if h.kind == "action" {
+			opts.Actions = append(opts.Actions, h.text)
+		}
+	}
+	written, err := writeExport(cfg, s, opts, path)
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	m.appendAction(formatAction(ActionExport, written))
+	return nil
+}
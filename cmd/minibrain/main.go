@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -12,9 +13,54 @@ import (
 
 func main() {
 	var useCLI bool
+	var resumeID string
 	flag.BoolVar(&useCLI, "cli", false, "run in CLI mode")
+	flag.StringVar(&resumeID, "resume", "", "resume the session with this id")
 	flag.Parse()
 
+	if resumeID != "" {
+		brainDir, err := agent.ResolveBrainDir()
+		if err == nil {
+			_, err = resumeSession(brainDir, resumeID)
+		}
+		if err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+	}
+
+	if flag.NArg() > 0 && flag.Arg(0) == "memory" {
+		if err := runMemoryCLI(flag.Args()[1:]); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.NArg() > 0 && flag.Arg(0) == "vault" {
+		if err := runVaultCLI(flag.Args()[1:]); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.NArg() > 0 && flag.Arg(0) == "audit" {
+		if err := runAuditCLI(flag.Args()[1:]); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.NArg() > 0 && flag.Arg(0) == "export" {
+		if err := runExportCLI(flag.Args()[1:]); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if useCLI {
 		prompt := strings.TrimSpace(strings.Join(flag.Args(), " "))
 		if prompt == "" {
@@ -31,11 +77,15 @@ func main() {
 			return
 		}
 
-		_, err := runAgent(prompt)
+		res, err := runAgent(prompt)
 		if err != nil {
 			fmt.Println("error:", err)
 			os.Exit(1)
 		}
+		if res.AuditErr != nil {
+			fmt.Println("error:", res.AuditErr)
+			os.Exit(1)
+		}
 
 		fmt.Println("done")
 		return
@@ -53,10 +103,15 @@ func runAgent(prompt string) (agent.Result, error) {
 	if err != nil {
 		return agent.Result{}, fmt.Errorf("failed to resolve brain dir: %w", err)
 	}
+	if _, err := currentSession(root, brainDir); err != nil {
+		return agent.Result{}, fmt.Errorf("failed to start session: %w", err)
+	}
 	perms := agent.ResolvePermissionState(root, readAllowedFromEnv(), writeAllowedFromEnv())
 	cfg := buildConfig(root, brainDir, configOptions{
-		allowRead:  perms.AllowRead,
-		allowWrite: perms.AllowWrite,
+		allowRead:     perms.AllowRead,
+		allowWrite:    perms.AllowWrite,
+		readApproval:  perms.ReadSource,
+		writeApproval: perms.WriteSource,
 	})
 
 	return agent.Run(prompt, cfg)
@@ -105,7 +160,170 @@ func runCLICommand(prompt string) (bool, error) {
 		}
 		_, err = agent.CondenseShortTerm(cfg)
 		return true, err
+	case "/checkpoints":
+		root, err := os.Getwd()
+		if err != nil {
+			return true, err
+		}
+		cps, err := agent.ListCheckpoints(root)
+		if err != nil {
+			return true, err
+		}
+		if len(cps) == 0 {
+			fmt.Println("no checkpoints")
+		}
+		for i := len(cps) - 1; i >= 0; i-- {
+			fmt.Println(agent.FormatCheckpoint(cps[i]))
+		}
+		return true, nil
+	case "/undo":
+		root, err := os.Getwd()
+		if err != nil {
+			return true, err
+		}
+		cp, err := agent.UndoLastCheckpoint(root)
+		if err != nil {
+			return true, err
+		}
+		if err := agent.RecordRestore(root, auditInfo("/undo"), cp); err != nil {
+			return true, fmt.Errorf("undone %s, but failed to write audit log: %w", cp.ID, err)
+		}
+		fmt.Println("undone:", cp.ID)
+		return true, nil
+	case "/sessions":
+		cfg, err := baseConfig()
+		if err != nil {
+			return true, err
+		}
+		sessions, err := agent.ListSessions(cfg.BrainDir)
+		if err != nil {
+			return true, err
+		}
+		for i := len(sessions) - 1; i >= 0; i-- {
+			marker := "  "
+			if sessions[i].ID == cfg.SessionID {
+				marker = "* "
+			}
+			fmt.Println(marker + agent.FormatSession(sessions[i]))
+		}
+		return true, nil
+	case "/new":
+		cfg, err := baseConfig()
+		if err != nil {
+			return true, err
+		}
+		promoteSessionCLI(cfg)
+		s, err := startNewSession(cfg.RootDir, cfg.BrainDir)
+		if err != nil {
+			return true, err
+		}
+		fmt.Println("new session:", s.ID)
+		return true, nil
+	case "/promote":
+		cfg, err := baseConfig()
+		if err != nil {
+			return true, err
+		}
+		p, err := agent.LoadPendingPromotion(cfg.BrainDir)
+		if err != nil {
+			return true, err
+		}
+		if p.Empty() {
+			if _, applied, err := agent.PromoteShortTerm(cfg); err != nil {
+				return true, err
+			} else if applied {
+				fmt.Println("long-term memory updated")
+				return true, nil
+			}
+			if p, err = agent.LoadPendingPromotion(cfg.BrainDir); err != nil {
+				return true, err
+			}
+		}
+		if p.Empty() {
+			fmt.Println("no memory changes to promote")
+			return true, nil
+		}
+		fmt.Print(agent.PromotionDiff(p))
+		fmt.Println("use /promote accept or /promote reject")
+		return true, nil
+	case "/promote accept":
+		cfg, err := baseConfig()
+		if err != nil {
+			return true, err
+		}
+		added, removed, err := agent.AcceptPendingPromotion(cfg.BrainDir)
+		if err != nil {
+			return true, err
+		}
+		fmt.Printf("promoted: %d added, %d removed\n", added, removed)
+		return true, nil
+	case "/promote reject":
+		cfg, err := baseConfig()
+		if err != nil {
+			return true, err
+		}
+		return true, agent.ClearPendingPromotion(cfg.BrainDir)
+	case "/diff":
+		root, err := os.Getwd()
+		if err != nil {
+			return true, err
+		}
+		diff, err := agent.WorktreeDiff(root)
+		if err != nil {
+			return true, err
+		}
+		fmt.Print(diff)
+		return true, nil
 	default:
+		if lower := strings.ToLower(strings.TrimSpace(prompt)); lower == "/commit" || strings.HasPrefix(lower, "/commit ") {
+			root, err := os.Getwd()
+			if err != nil {
+				return true, err
+			}
+			message := strings.TrimSpace(strings.TrimSpace(prompt)[len("/commit"):])
+			res, err := agent.CommitAll(root, applyConfig(root), message)
+			if err != nil {
+				return true, err
+			}
+			fmt.Println("committed:", res.Commit, agent.FirstLine(res.Message))
+			return true, nil
+		}
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(prompt)), "/resume") {
+			fields := strings.Fields(prompt)
+			if len(fields) < 2 {
+				return true, errors.New("usage: /resume <id>")
+			}
+			cfg, err := baseConfig()
+			if err != nil {
+				return true, err
+			}
+			promoteSessionCLI(cfg)
+			s, err := resumeSession(cfg.BrainDir, fields[1])
+			if err != nil {
+				return true, err
+			}
+			fmt.Println("resumed:", agent.FormatSession(s))
+			return true, nil
+		}
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(prompt)), "/restore") {
+			fields := strings.Fields(prompt)
+			if len(fields) < 2 {
+				return true, errors.New("usage: /restore <id>")
+			}
+			root, err := os.Getwd()
+			if err != nil {
+				return true, err
+			}
+			cp, err := agent.RestoreCheckpoint(root, fields[1])
+			if err != nil {
+				return true, err
+			}
+			if err := agent.RecordRestore(root, auditInfo("/restore"), cp); err != nil {
+				return true, fmt.Errorf("restored %s, but failed to write audit log: %w", cp.ID, err)
+			}
+			fmt.Println("restored:", cp.ID)
+			return true, nil
+		}
 		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(prompt)), "/model ") {
 			fields := strings.Fields(prompt)
 			if len(fields) < 2 {
@@ -130,6 +348,19 @@ func runCLICommand(prompt string) (bool, error) {
 	}
 }
 
+// promoteSessionCLI runs the promotion pass for the session being left.
+func promoteSessionCLI(cfg agent.Config) {
+	p, applied, err := agent.PromoteShortTerm(cfg)
+	switch {
+	case err != nil:
+		fmt.Println("memory promotion failed:", err)
+	case applied:
+		fmt.Println("long-term memory updated:", agent.FormatPromotionSummary(p))
+	case !p.Empty():
+		fmt.Println("memory changes proposed:", agent.FormatPromotionSummary(p), "(run /promote to review)")
+	}
+}
+
 func readAllowedFromEnv() bool {
 	v := strings.ToLower(strings.TrimSpace(os.Getenv("MINIBRAIN_ALLOW_READ")))
 	return v == "1" || v == "true" || v == "yes"
@@ -139,3 +370,13 @@ func writeAllowedFromEnv() bool {
 	v := strings.ToLower(strings.TrimSpace(os.Getenv("MINIBRAIN_ALLOW_WRITE")))
 	return v == "1" || v == "true" || v == "yes"
 }
+
+func autoPromoteFromEnv() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("MINIBRAIN_AUTO_PROMOTE")))
+	return v == "1" || v == "true" || v == "yes"
+}
+
+func gitEnabledFromEnv() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("MINIBRAIN_GIT")))
+	return v == "1" || v == "true" || v == "yes"
+}
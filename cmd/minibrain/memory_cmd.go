@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// memorySubcommand runs the memory commands shared by the TUI and
+// `minibrain memory ...`, returning the lines to show.
+func memorySubcommand(cfg agent.Config, args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, errors.New("usage: memory add|list|forget|edit|log|revert|history|restore")
+	}
+	rest := strings.TrimSpace(strings.Join(args[1:], " "))
+	switch strings.ToLower(args[0]) {
+	case "add", "remember":
+		e, err := agent.RememberFact(cfg, rest)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"remembered " + agent.FormatMemoryEntry(e)}, nil
+	case "list", "ls", "search":
+		entries, err := agent.FindMemories(cfg, rest)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			return []string{"no memories"}, nil
+		}
+		lines := make([]string, 0, len(entries))
+		for _, e := range entries {
+			lines = append(lines, agent.FormatMemoryEntry(e))
+		}
+		return lines, nil
+	case "forget", "rm":
+		e, err := agent.ForgetMemory(cfg, rest)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"forgot " + agent.FormatMemoryEntry(e)}, nil
+	case "log":
+		changes, err := agent.ListMemoryChanges(cfg.BrainDir)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			return []string{"no memory changes"}, nil
+		}
+		var lines []string
+		for i := len(changes) - 1; i >= 0; i-- {
+			lines = append(lines, agent.FormatMemoryChange(changes[i]))
+		}
+		return lines, nil
+	case "revert":
+		if rest == "" {
+			return nil, errors.New("usage: memory revert <change-id>")
+		}
+		c, err := agent.RevertMemoryChange(cfg, rest)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"reverted " + rest + " (" + c.ID + ")"}, nil
+	case "history":
+		versions, err := agent.ListBrainHistory(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if rest != "" {
+			for _, v := range versions {
+				if v.TS == rest {
+					return append([]string{agent.FormatBrainVersion(v)}, strings.Split(strings.TrimRight(v.Diff, "\n"), "\n")...), nil
+				}
+			}
+			return nil, fmt.Errorf("no history entry %s", rest)
+		}
+		if len(versions) == 0 {
+			return []string{"no history"}, nil
+		}
+		var lines []string
+		for i := len(versions) - 1; i >= 0; i-- {
+			lines = append(lines, agent.FormatBrainVersion(versions[i]))
+		}
+		return lines, nil
+	case "restore":
+		if rest == "" {
+			return nil, errors.New("usage: memory restore <ts>")
+		}
+		v, err := agent.RestoreBrainVersion(cfg, rest)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"restored " + v.Path + " to its state before " + v.TS}, nil
+	}
+	return nil, fmt.Errorf("unknown memory command %q", args[0])
+}
+
+// memoryEditTemp copies the memory file for target into a temp file for the
+// editor and returns both paths.
+func memoryEditTemp(cfg agent.Config, target string) (string, string, agent.MemoryScope, error) {
+	path, scope, err := agent.MemoryEditTarget(cfg, target)
+	if err != nil {
+		return "", "", "", err
+	}
+	b, err := vault.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", "", err
+	}
+	f, err := os.CreateTemp("", "minibrain-memory-*.md")
+	if err != nil {
+		return "", "", "", err
+	}
+	_, err = f.Write(b)
+	_ = f.Close()
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", "", "", err
+	}
+	return path, f.Name(), scope, nil
+}
+
+// saveMemoryEdit validates the edited temp file and saves it. The temp file
+// is removed on success and kept on failure so the edit is not lost.
+func saveMemoryEdit(cfg agent.Config, path, tmp string, scope agent.MemoryScope) (string, error) {
+	b, err := os.ReadFile(tmp)
+	if err != nil {
+		return "", err
+	}
+	c, err := agent.SaveMemoryFile(cfg, path, scope, string(b))
+	if err != nil {
+		return "", fmt.Errorf("%w; your edit is kept in %s", err, tmp)
+	}
+	_ = os.Remove(tmp)
+	if c.ID == "" {
+		return "no changes", nil
+	}
+	return "saved " + path + " (" + c.ID + ")", nil
+}
+
+func runMemoryCLI(args []string) error {
+	cfg, err := baseConfig()
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 && strings.EqualFold(args[0], "edit") {
+		target := ""
+		if len(args) > 1 {
+			target = args[1]
+		}
+		path, tmp, scope, err := memoryEditTemp(cfg, target)
+		if err != nil {
+			return err
+		}
+		cmd := editorCommand(tmp)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+		msg, err := saveMemoryEdit(cfg, path, tmp, scope)
+		if err != nil {
+			return err
+		}
+		fmt.Println(msg)
+		return nil
+	}
+	lines, err := memorySubcommand(cfg, args)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		fmt.Println(l)
+	}
+	return nil
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/chrishannah/minibrain/internal/agent"
 )
 
-func runAgentStreamWithAllow(prompt string, allowRead, allowWrite bool, onDelta func(string)) (agent.Result, error) {
+func runAgentStreamWithOptions(prompt string, opts configOptions, onDelta func(string)) (agent.Result, error) {
 	root, err := os.Getwd()
 	if err != nil {
 		return agent.Result{}, fmt.Errorf("failed to get working directory: %w", err)
@@ -16,26 +16,9 @@ func runAgentStreamWithAllow(prompt string, allowRead, allowWrite bool, onDelta
 	if err != nil {
 		return agent.Result{}, fmt.Errorf("failed to resolve brain dir: %w", err)
 	}
-	cfg := buildConfig(root, brainDir, configOptions{
-		allowRead:  allowRead,
-		allowWrite: allowWrite,
-	})
-	return agent.RunStream(prompt, cfg, onDelta)
-}
-
-func runAgentStreamWithAllowAndReads(prompt string, allowRead, allowWrite bool, readPaths []string, onDelta func(string)) (agent.Result, error) {
-	root, err := os.Getwd()
-	if err != nil {
-		return agent.Result{}, fmt.Errorf("failed to get working directory: %w", err)
-	}
-	brainDir, err := agent.ResolveBrainDir()
-	if err != nil {
-		return agent.Result{}, fmt.Errorf("failed to resolve brain dir: %w", err)
+	if _, err := currentSession(root, brainDir); err != nil {
+		return agent.Result{}, fmt.Errorf("failed to start session: %w", err)
 	}
-	cfg := buildConfig(root, brainDir, configOptions{
-		allowRead:  allowRead,
-		allowWrite: allowWrite,
-		readPaths:  readPaths,
-	})
+	cfg := buildConfig(root, brainDir, opts)
 	return agent.RunStream(prompt, cfg, onDelta)
 }
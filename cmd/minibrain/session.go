@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+// activeSessionID is the session used by this process. It starts empty and
+// is resolved lazily to the latest session for the project.
+var activeSessionID string
+
+// existingSession returns the active session, or the project's latest one,
+// without creating anything.
+func existingSession(root, brainDir string) (agent.Session, bool) {
+	if activeSessionID != "" {
+		if s, err := agent.LoadSession(brainDir, activeSessionID); err == nil {
+			return s, true
+		}
+	}
+	if s, ok := agent.LatestSession(brainDir, root); ok {
+		activeSessionID = s.ID
+		return s, true
+	}
+	return agent.Session{}, false
+}
+
+// currentSession is existingSession, starting a new session when there is
+// none yet. Only a turn needs one.
+func currentSession(root, brainDir string) (agent.Session, error) {
+	if s, ok := existingSession(root, brainDir); ok {
+		return s, nil
+	}
+	return startNewSession(root, brainDir)
+}
+
+func startNewSession(root, brainDir string) (agent.Session, error) {
+	s, err := agent.NewSession(brainDir, root, currentModel())
+	if err != nil {
+		return agent.Session{}, err
+	}
+	activeSessionID = s.ID
+	return s, nil
+}
+
+// resumeSession makes id the active session and marks it as the most
+// recently used, so later runs in the same project pick it up.
+func resumeSession(brainDir, id string) (agent.Session, error) {
+	s, err := agent.LoadSession(brainDir, id)
+	if err != nil {
+		return agent.Session{}, err
+	}
+	s, err = agent.TouchSession(brainDir, s.ID, "")
+	if err != nil {
+		return agent.Session{}, err
+	}
+	activeSessionID = s.ID
+	return s, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
@@ -94,14 +95,81 @@ func (m *tuiModel) appendRunResult(res agent.Result) {
 	for _, p := range res.AppliedPatches {
 		m.appendAction(formatAction(ActionPatch, p.Path))
 	}
-	for _, p := range res.FailedPatches {
-		m.appendAction(formatAction(ActionPatchFailed, p.Path+" ("+p.Reason+")"))
+	for _, e := range res.AppliedEdits {
+		m.appendAction(formatAction(ActionEdit, e.Path))
 	}
+	m.appendChangeOutcome(res.Report)
 	if res.Condensed {
 		m.appendAction(formatAction(ActionMemory, "CONDENSED"))
+		m.notePendingPromotion()
 	}
 }
 
+func (m *tuiModel) appendChangeOutcome(report agent.ChangeReport) {
+	if report.Checkpoint != "" {
+		m.appendAction(formatAction(ActionCheckpoint, report.Checkpoint+" (use /undo to revert)"))
+	}
+	previewed := map[string]struct{}{}
+	normalized := map[string]struct{}{}
+	for _, r := range report.Results {
+		if r.Normalized != "" {
+			if _, ok := normalized[r.Path]; !ok {
+				normalized[r.Path] = struct{}{}
+				m.appendAction(formatAction(ActionInfo, r.Path+": "+r.Normalized))
+			}
+		}
+		if r.Status == agent.OpConflict {
+			m.appendAction(formatAction(ActionConflict, r.Path+" ("+r.Reason+")"))
+			if _, ok := previewed[r.Path]; !ok && r.Merged != "" {
+				previewed[r.Path] = struct{}{}
+				for _, region := range agent.ConflictRegions(r.Merged, 2) {
+					m.appendPreview(formatPreviewBlock("CONFLICT", r.Path, region))
+				}
+			}
+			continue
+		}
+		if r.Status != agent.OpFailed {
+			continue
+		}
+		kind := ActionPatchFailed
+		switch r.Kind {
+		case "WRITE":
+			kind = ActionWriteFailed
+		case "DELETE":
+			kind = ActionDeleteFailed
+		case "EDIT":
+			kind = ActionEditFailed
+		}
+		m.appendAction(formatAction(kind, r.Path+" ("+r.Reason+")"))
+	}
+	if report.Err != nil {
+		m.appendAction(formatAction(ActionChangesRollback, report.Err.Error()))
+	}
+	if g := report.Git; g != nil {
+		if g.Branch != "" {
+			m.appendAction(formatAction(ActionGit, "BRANCH "+g.Branch))
+		}
+		if g.Commit != "" {
+			m.appendAction(formatAction(ActionGit, "COMMIT "+g.Commit+" "+agent.FirstLine(g.Message)))
+		}
+		if g.Err != nil && report.Err == nil {
+			m.appendAction(formatAction(ActionError, "git: "+g.Err.Error()))
+		}
+	}
+}
+
+func (m *tuiModel) offerConflictResolution(conflicts []agent.OpResult) {
+	m.pendingConflicts = conflicts
+	m.status = "Ready"
+	m.appendPermission("CONFLICT: files were edited after minibrain read them. Nothing was applied. Choose how to resolve:")
+	m.appendChoice("conflict", "Choose:", []string{
+		"/resolve markers write merged files with conflict markers",
+		"/resolve mine keep the on-disk version",
+		"/resolve theirs overwrite with minibrain's version",
+		"/deny discard all pending changes",
+	})
+}
+
 func (m *tuiModel) appendRaw(text string) {
 	if strings.TrimSpace(text) == "" {
 		return
@@ -140,26 +208,53 @@ func formatPreviewBlock(kind, path string, lines []string) string {
 }
 
 func appendChangePreview(m *tuiModel) {
-	const maxLines = 12
+	const maxLines = 40
+	root, _ := os.Getwd()
 	for _, p := range m.pendingPatches {
-		lines := strings.Split(p.Patch, "\n")
+		lines := strings.Split(strings.TrimRight(p.Patch, "\n"), "\n")
 		if len(lines) > maxLines {
 			lines = append(lines[:maxLines], "...")
 		}
-		m.appendPreview(formatPreviewBlock("PATCH", p.Path, lines))
+		added, removed := agent.PatchStats(p.Patch)
+		m.appendPreview(formatPreviewBlock("PATCH", p.Path+formatLineStats(added, removed), lines))
 	}
-	for _, w := range m.pendingWrites {
-		lines := strings.Split(w.Content, "\n")
+	for _, w := range agent.AnnotateWriteStats(root, m.pendingWrites) {
+		var lines []string
+		if diff := agent.WriteDiff(root, w); diff == "" {
+			lines = []string{"(no changes)"}
+		} else {
+			lines = strings.Split(strings.TrimRight(diff, "\n"), "\n")[2:]
+		}
+		if len(lines) > maxLines {
+			lines = append(lines[:maxLines], "...")
+		}
+		m.appendPreview(formatPreviewBlock("WRITE", w.Path+formatLineStats(w.Added, w.Removed), lines))
+	}
+	for _, e := range m.pendingEdits {
+		writes, err := agent.EditsToWrites(root, []agent.EditOp{e})
+		if err != nil {
+			m.appendAction(formatAction(ActionEditFailed, err.Error()))
+			continue
+		}
+		w := agent.AnnotateWriteStats(root, writes)[0]
+		lines := []string{"(no changes)"}
+		if diff := agent.WriteDiff(root, w); diff != "" {
+			lines = strings.Split(strings.TrimRight(diff, "\n"), "\n")[2:]
+		}
 		if len(lines) > maxLines {
 			lines = append(lines[:maxLines], "...")
 		}
-		m.appendPreview(formatPreviewBlock("WRITE", w.Path, lines))
+		m.appendPreview(formatPreviewBlock("EDIT", e.Path+formatLineStats(w.Added, w.Removed), lines))
 	}
 	for _, d := range m.pendingDeletes {
 		m.appendPreview(formatPreviewBlock("DELETE", d.Path, nil))
 	}
 }
 
+func formatLineStats(added, removed int) string {
+	return fmt.Sprintf(" (+%d -%d)", added, removed)
+}
+
 func (m *tuiModel) refreshViewport() {
 	var b strings.Builder
 	actionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorPrimary))
@@ -184,18 +279,11 @@ func (m *tuiModel) refreshViewport() {
 }
 
 func initialStats() (agent.MemoryStats, error) {
-	brainDir, err := agent.ResolveBrainDir()
-	if err != nil {
-		return agent.MemoryStats{}, err
-	}
-	root, err := os.Getwd()
+	cfg, err := baseConfig()
 	if err != nil {
 		return agent.MemoryStats{}, err
 	}
-	if err := agent.EnsureBrainLayout(brainDir, root); err != nil {
-		return agent.MemoryStats{}, err
-	}
-	return agent.GetMemoryStats(brainDir, "", "")
+	return agent.GetMemoryStats(cfg.BrainDir, cfg.NeoPath, cfg.ProjectNeoPath, cfg.PrefrontalPath)
 }
 
 func (m *tuiModel) updateMarkdownRenderer() {
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
@@ -40,14 +42,16 @@ func startAgentStream(m *tuiModel, prompt string, allowRead, allowWrite bool, re
 	} else {
 		m.status = "Thinking"
 	}
+	opts := configOptions{
+		allowRead:     allowRead,
+		allowWrite:    allowWrite,
+		readPaths:     readPaths,
+		approvals:     m.readApprovals,
+		readApproval:  m.readSource,
+		writeApproval: m.writeSource,
+	}
 	go func() {
-		var res agent.Result
-		var err error
-		if len(readPaths) > 0 {
-			res, err = runAgentStreamWithAllowAndReads(prompt, allowRead, allowWrite, readPaths, nil)
-		} else {
-			res, err = runAgentStreamWithAllow(prompt, allowRead, allowWrite, nil)
-		}
+		res, err := runAgentStreamWithOptions(prompt, opts, nil)
 		ch <- streamMsg{done: true, res: res, err: err}
 		close(ch)
 	}()
@@ -66,7 +70,7 @@ func runMemoryCmd(prompt string) tea.Cmd {
 			if err := agent.ClearShortTerm(cfg); err != nil {
 				return memMsg{err: err}
 			}
-			stats, _ := agent.GetMemoryStats(cfg.BrainDir, cfg.NeoPath, cfg.PrefrontalPath)
+			stats, _ := agent.GetMemoryStats(cfg.BrainDir, cfg.NeoPath, cfg.ProjectNeoPath, cfg.PrefrontalPath)
 			return memMsg{action: "MEMORY CLEARED", stats: stats}
 		}
 	case "/condense":
@@ -79,7 +83,7 @@ func runMemoryCmd(prompt string) tea.Cmd {
 			if err != nil {
 				return memMsg{err: err}
 			}
-			stats, _ := agent.GetMemoryStats(cfg.BrainDir, cfg.NeoPath, cfg.PrefrontalPath)
+			stats, _ := agent.GetMemoryStats(cfg.BrainDir, cfg.NeoPath, cfg.ProjectNeoPath, cfg.PrefrontalPath)
 			return memMsg{action: formatAction(ActionMemory, "CONDENSED"), stats: stats, condensed: true}
 		}
 	default:
@@ -127,7 +131,7 @@ func normalizePermissionResponse(s string) string {
 	if strings.HasPrefix(v, "/") {
 		return v
 	}
-	if v == "yes" || v == "no" || v == "always" {
+	if v == "yes" || v == "no" || v == "always" || v == "never" {
 		return "/" + v
 	}
 	return v
@@ -171,37 +175,53 @@ func handleApplyCommand(m *tuiModel, cmd string) tea.Cmd {
 	case "/apply":
 		m.allowWriteAll = true
 		m.denyWriteAll = false
-		return applyPending(m, false)
+		m.writeSource = cmd
+		recordWritePermission(m, cmd, "session allow")
+		return applyPending(m, false, cmd)
 	case "/apply-always":
 		m.allowWriteAll = true
 		m.denyWriteAll = false
-		m.projectCfg.AllowWriteAlways = true
-		m.projectCfg.DenyWriteAlways = false
-		if err := saveProjectConfig(m); err != nil {
+		m.writeSource = cmd
+		if err := updateProjectConfig(m, func(cfg *agent.ProjectConfig) {
+			cfg.AllowWriteAlways = true
+			cfg.DenyWriteAlways = false
+		}); err != nil {
 			m.appendAction(formatAction(ActionError, err.Error()))
 		}
-		return applyPending(m, true)
+		recordWritePermission(m, cmd, "always allow")
+		return applyPending(m, true, cmd)
 	case "/deny":
 		m.pendingWrites = nil
 		m.pendingDeletes = nil
 		m.pendingPatches = nil
+		m.pendingEdits = nil
+		m.pendingRefs = nil
+		m.pendingConflicts = nil
 		m.pendingPrefrontal = ""
 		m.pendingPreviewed = false
 		m.allowWriteAll = false
 		m.denyWriteAll = true
+		m.writeSource = ""
+		recordWritePermission(m, cmd, "session deny")
 		m.appendAction(formatAction(ActionChangesDenied, "session"))
 		return nil
 	case "/deny-always":
 		m.allowWriteAll = false
 		m.denyWriteAll = true
+		m.writeSource = ""
+		recordWritePermission(m, cmd, "always deny")
 		m.pendingWrites = nil
 		m.pendingDeletes = nil
 		m.pendingPatches = nil
+		m.pendingEdits = nil
+		m.pendingRefs = nil
+		m.pendingConflicts = nil
 		m.pendingPrefrontal = ""
 		m.pendingPreviewed = false
-		m.projectCfg.AllowWriteAlways = false
-		m.projectCfg.DenyWriteAlways = true
-		if err := saveProjectConfig(m); err != nil {
+		if err := updateProjectConfig(m, func(cfg *agent.ProjectConfig) {
+			cfg.AllowWriteAlways = false
+			cfg.DenyWriteAlways = true
+		}); err != nil {
 			m.appendAction(formatAction(ActionError, err.Error()))
 		}
 		m.appendAction(formatAction(ActionChangesDenied, "always"))
@@ -211,8 +231,10 @@ func handleApplyCommand(m *tuiModel, cmd string) tea.Cmd {
 	}
 }
 
-func applyPending(m *tuiModel, always bool) tea.Cmd {
-	if len(m.pendingWrites) == 0 && len(m.pendingDeletes) == 0 && len(m.pendingPatches) == 0 {
+// applyPending applies the pending changes; approval names the command or
+// setting that allowed it, for the audit log.
+func applyPending(m *tuiModel, always bool, approval string) tea.Cmd {
+	if len(m.pendingWrites) == 0 && len(m.pendingDeletes) == 0 && len(m.pendingPatches) == 0 && len(m.pendingEdits) == 0 {
 		m.appendAction(formatAction(ActionInfo, "No pending changes"))
 		return nil
 	}
@@ -222,38 +244,237 @@ func applyPending(m *tuiModel, always bool) tea.Cmd {
 		m.appendAction(formatAction(ActionError, err.Error()))
 		return nil
 	}
-	appliedWrites := agent.ApplyWrites(root, m.pendingWrites)
-	appliedDeletes := agent.ApplyDeletes(root, m.pendingDeletes)
-	appliedPatches, failedPatches := agent.ApplyPatches(root, m.pendingPatches)
+	cfg := applyConfig(root)
+	applyStart := time.Now()
+	report := agent.ApplyChanges(root, agent.ChangeSet{
+		Writes:  m.pendingWrites,
+		Deletes: m.pendingDeletes,
+		Patches: m.pendingPatches,
+		Edits:   m.pendingEdits,
+		Reads:   m.pendingRefs,
+	}, cfg, m.lastPrompt)
+	if err := agent.RecordChanges(root, auditInfo(approval), report); err != nil {
+		m.appendAction(formatAction(ActionError, "audit log: "+err.Error()))
+	}
 	if m.pendingPrefrontal != "" {
-		agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatWritesSummary(appliedWrites))
-		agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatDeletesSummary(appliedDeletes))
-		agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatPatchesSummary(appliedPatches))
+		_ = agent.RecordApply(cfg, m.pendingPrefrontal, report, time.Since(applyStart))
+		agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatWritesSummary(report.Writes))
+		agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatDeletesSummary(report.Deletes))
+		agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatPatchesSummary(report.Patches))
+		agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatEditsSummary(report.Edits))
+		if summary := agent.FormatNormalizedSummary(report); summary != "" {
+			agent.AppendPrefrontal(m.pendingPrefrontal, summary)
+		}
+		if summary := agent.FormatGitSummary(report.Git); summary != "" {
+			agent.AppendPrefrontal(m.pendingPrefrontal, summary)
+		}
+		if !report.Committed {
+			agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatChangeReport(report))
+		}
 	}
-	for _, w := range appliedWrites {
+	for _, w := range report.Writes {
 		m.appendAction(formatAction(ActionWrite, w.Path))
 	}
-	for _, d := range appliedDeletes {
+	for _, d := range report.Deletes {
 		m.appendAction(formatAction(ActionDelete, d.Path))
 	}
-	for _, p := range appliedPatches {
+	for _, p := range report.Patches {
 		m.appendAction(formatAction(ActionPatch, p.Path))
 	}
-	for _, p := range failedPatches {
-		m.appendAction(formatAction(ActionPatchFailed, p.Path+" ("+p.Reason+")"))
+	for _, e := range report.Edits {
+		m.appendAction(formatAction(ActionEdit, e.Path))
 	}
+	m.appendChangeOutcome(report)
 	if always {
 		m.appendAction(formatAction(ActionChangesAuto, ""))
 	}
+	if conflicts := report.Conflicts(); len(conflicts) > 0 {
+		m.offerConflictResolution(conflicts)
+		return nil
+	}
+	m.pendingConflicts = nil
 	m.pendingWrites = nil
 	m.pendingDeletes = nil
 	m.pendingPatches = nil
+	m.pendingEdits = nil
+	m.pendingRefs = nil
 	m.pendingPrefrontal = ""
 	m.pendingPreviewed = false
 	m.status = "Ready"
 	return nil
 }
 
+func handleResolveCommand(m *tuiModel, prompt string) tea.Cmd {
+	if len(m.pendingConflicts) == 0 {
+		m.appendAction(formatAction(ActionInfo, "No conflicts to resolve"))
+		return nil
+	}
+	fields := strings.Fields(strings.ToLower(prompt))
+	if len(fields) < 2 {
+		m.appendAction(formatAction(ActionInfo, "Usage: /resolve markers|mine|theirs"))
+		return nil
+	}
+	conflicted := map[string]agent.OpResult{}
+	for _, c := range m.pendingConflicts {
+		conflicted[c.Path] = c
+	}
+	isConflicted := func(path string) bool {
+		_, ok := conflicted[filepath.Clean(path)]
+		return ok
+	}
+	switch fields[1] {
+	case "mine":
+		m.pendingWrites = filterWrites(m.pendingWrites, isConflicted)
+		m.pendingDeletes = filterDeletes(m.pendingDeletes, isConflicted)
+		m.pendingPatches = filterPatches(m.pendingPatches, isConflicted)
+		m.pendingEdits = filterEdits(m.pendingEdits, isConflicted)
+	case "theirs":
+		// Patches and edits were made against content that has since
+		// changed, so a conflicted file is written whole with minibrain's
+		// version where there is one, and otherwise keeps only its writes
+		// and deletes.
+		m.pendingPatches = filterPatches(m.pendingPatches, isConflicted)
+		m.pendingEdits = filterEdits(m.pendingEdits, isConflicted)
+		m.pendingRefs = filterRefs(m.pendingRefs, isConflicted)
+		var whole []agent.WriteOp
+		merged := map[string]bool{}
+		for _, c := range m.pendingConflicts {
+			if c.Merged != "" && !merged[c.Path] {
+				merged[c.Path] = true
+				whole = append(whole, agent.WriteOp{Path: c.Path, Content: c.Theirs})
+			}
+		}
+		hasTheirs := func(path string) bool { return merged[filepath.Clean(path)] }
+		m.pendingWrites = append(filterWrites(m.pendingWrites, hasTheirs), whole...)
+		m.pendingDeletes = filterDeletes(m.pendingDeletes, hasTheirs)
+	case "markers":
+		m.pendingWrites = filterWrites(m.pendingWrites, isConflicted)
+		m.pendingDeletes = filterDeletes(m.pendingDeletes, isConflicted)
+		m.pendingPatches = filterPatches(m.pendingPatches, isConflicted)
+		m.pendingEdits = filterEdits(m.pendingEdits, isConflicted)
+		m.pendingRefs = filterRefs(m.pendingRefs, isConflicted)
+		for _, c := range m.pendingConflicts {
+			if c.Merged == "" {
+				continue
+			}
+			if _, ok := conflicted[c.Path]; !ok {
+				continue
+			}
+			delete(conflicted, c.Path)
+			m.pendingWrites = append(m.pendingWrites, agent.WriteOp{Path: c.Path, Content: c.Merged})
+		}
+	default:
+		m.appendAction(formatAction(ActionInfo, "Usage: /resolve markers|mine|theirs"))
+		return nil
+	}
+	m.pendingConflicts = nil
+	m.appendAction(formatAction(ActionConflict, "RESOLVED "+fields[1]))
+	return applyPending(m, false, "/resolve "+fields[1])
+}
+
+func filterWrites(ops []agent.WriteOp, drop func(string) bool) []agent.WriteOp {
+	var out []agent.WriteOp
+	for _, op := range ops {
+		if !drop(op.Path) {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+func filterDeletes(ops []agent.DeleteOp, drop func(string) bool) []agent.DeleteOp {
+	var out []agent.DeleteOp
+	for _, op := range ops {
+		if !drop(op.Path) {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+func filterPatches(ops []agent.PatchOp, drop func(string) bool) []agent.PatchOp {
+	var out []agent.PatchOp
+	for _, op := range ops {
+		if !drop(op.Path) {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+func filterEdits(ops []agent.EditOp, drop func(string) bool) []agent.EditOp {
+	var out []agent.EditOp
+	for _, op := range ops {
+		if !drop(op.Path) {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+func filterRefs(refs []agent.FileRef, drop func(string) bool) []agent.FileRef {
+	var out []agent.FileRef
+	for _, r := range refs {
+		if !drop(r.Path) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func handleCheckpointCommand(m *tuiModel, prompt string) tea.Cmd {
+	root, err := os.Getwd()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	fields := strings.Fields(prompt)
+	switch strings.ToLower(fields[0]) {
+	case "/checkpoints":
+		cps, err := agent.ListCheckpoints(root)
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		if len(cps) == 0 {
+			m.appendAction(formatAction(ActionInfo, "No checkpoints"))
+			return nil
+		}
+		for i := len(cps) - 1; i >= 0; i-- {
+			m.appendAction(formatAction(ActionCheckpoint, agent.FormatCheckpoint(cps[i])))
+		}
+	case "/undo":
+		cp, err := agent.UndoLastCheckpoint(root)
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		if err := agent.RecordRestore(root, auditInfo("/undo"), cp); err != nil {
+			m.appendAction(formatAction(ActionError, "audit log: "+err.Error()))
+		}
+		m.appendAction(formatAction(ActionCheckpoint, "UNDONE "+cp.ID))
+	case "/restore":
+		if len(fields) < 2 {
+			m.appendAction(formatAction(ActionInfo, "Usage: /restore <id>"))
+			return nil
+		}
+		cp, err := agent.RestoreCheckpoint(root, fields[1])
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		if err := agent.RecordRestore(root, auditInfo("/restore"), cp); err != nil {
+			m.appendAction(formatAction(ActionError, "audit log: "+err.Error()))
+		}
+		m.appendAction(formatAction(ActionCheckpoint, "RESTORED "+cp.ID))
+	}
+	return nil
+}
+
+func applyOptions() []string {
+	return []string{"/apply allow for session", "/review review hunk by hunk", "/apply-always always apply", "/deny deny for session", "/deny-always always deny"}
+}
+
 func helpLines() []string {
 	return []string{
 		"/help  Show commands",
@@ -264,13 +485,33 @@ func helpLines() []string {
 		"/usage  Show memory and token usage",
 		"/actions  Toggle action log",
 		"/raw  Toggle raw model output",
-		"/yes  Allow reading for session",
-		"/no  Deny reading for session",
-		"/always  Always allow reading",
+		"/yes  Allow reading the listed files for session",
+		"/no  Deny reading the listed files for session",
+		"/always  Always allow reading the listed files",
+		"/never  Never allow reading the listed files",
+		"/read [allow|deny <path|glob> [always]]  Show or set per-file read approvals",
 		"/apply  Apply and allow writes for session",
 		"/apply-always  Always apply writes/deletes",
+		"/review  Review pending changes hunk by hunk",
 		"/deny  Deny writes for session",
 		"/deny-always  Always deny writes/deletes",
+		"/resolve markers|mine|theirs  Resolve conflicting changes",
+		"/undo  Revert the last applied changes",
+		"/checkpoints  List checkpoints",
+		"/restore <id>  Restore files to a checkpoint",
+		"/diff  Show uncommitted git changes",
+		"/remember <fact>  Add a long-term memory",
+		"/memories [query]  List or search long-term memory",
+		"/forget <id|query>  Remove a long-term memory",
+		"/memory edit [neo|project|prefrontal]  Edit a memory file in $EDITOR",
+		"/memory log, /memory revert <id>  Audit and revert memory changes",
+		"/memory history [ts], /memory restore <ts>  Browse and restore brain file versions",
+		"/promote  Review proposed long-term memory changes",
+		"/sessions  List sessions",
+		"/resume <id>  Switch to another session",
+		"/new  Start a new session",
+		"/export [md|html|json] [path]  Export this session to a file",
+		"/commit [message]  Commit all changes (message generated if omitted)",
 	}
 }
 
@@ -284,13 +525,31 @@ func helpItems() []commandItem {
 		{cmd: "/usage", desc: "Show memory and token usage"},
 		{cmd: "/actions", desc: "Toggle action log"},
 		{cmd: "/raw", desc: "Toggle raw model output"},
-		{cmd: "/yes", desc: "Allow reading for session"},
-		{cmd: "/no", desc: "Deny reading for session"},
-		{cmd: "/always", desc: "Always allow reading"},
+		{cmd: "/yes", desc: "Allow reading the listed files for session"},
+		{cmd: "/no", desc: "Deny reading the listed files for session"},
+		{cmd: "/always", desc: "Always allow reading the listed files"},
+		{cmd: "/never", desc: "Never allow reading the listed files"},
+		{cmd: "/read", desc: "Show or set per-file read approvals"},
 		{cmd: "/apply", desc: "Apply and allow writes for session"},
 		{cmd: "/apply-always", desc: "Always apply writes/deletes"},
+		{cmd: "/review", desc: "Review pending changes hunk by hunk"},
 		{cmd: "/deny", desc: "Deny writes for session"},
 		{cmd: "/deny-always", desc: "Always deny writes/deletes"},
+		{cmd: "/resolve", desc: "Resolve conflicting changes"},
+		{cmd: "/undo", desc: "Revert the last applied changes"},
+		{cmd: "/checkpoints", desc: "List checkpoints"},
+		{cmd: "/restore", desc: "Restore files to a checkpoint"},
+		{cmd: "/diff", desc: "Show uncommitted git changes"},
+		{cmd: "/remember", desc: "Add a long-term memory"},
+		{cmd: "/memories", desc: "List or search long-term memory"},
+		{cmd: "/forget", desc: "Remove a long-term memory"},
+		{cmd: "/memory", desc: "Edit, log, revert or restore memory"},
+		{cmd: "/promote", desc: "Review proposed long-term memory changes"},
+		{cmd: "/sessions", desc: "List sessions"},
+		{cmd: "/resume", desc: "Switch to another session"},
+		{cmd: "/new", desc: "Start a new session"},
+		{cmd: "/export", desc: "Export this session to a file"},
+		{cmd: "/commit", desc: "Commit all changes with a generated message"},
 	}
 }
 
@@ -377,11 +636,23 @@ func applyChoice(m *tuiModel) tea.Cmd {
 
 	switch m.choiceKind {
 	case "read":
-		cmd := strings.Fields(selected)[0]
-		return submitPrompt(m, cmd)
+		fields := strings.Fields(selected)
+		if fields[0] == "/read" && len(fields) > 2 {
+			return submitPrompt(m, strings.Join(fields[:3], " "))
+		}
+		return submitPrompt(m, fields[0])
 	case "apply":
 		cmd := strings.Fields(selected)[0]
 		return submitPrompt(m, cmd)
+	case "promote":
+		fields := strings.Fields(selected)
+		return submitPrompt(m, fields[0]+" "+fields[1])
+	case "conflict":
+		fields := strings.Fields(selected)
+		if fields[0] == "/resolve" && len(fields) > 1 {
+			return submitPrompt(m, fields[0]+" "+fields[1])
+		}
+		return submitPrompt(m, fields[0])
 	case "model":
 		m.running = true
 		m.appendUser(selected)
@@ -400,81 +671,12 @@ func applyChoice(m *tuiModel) tea.Cmd {
 
 func submitPrompt(m *tuiModel, prompt string) tea.Cmd {
 	if m.pendingPrompt != "" {
-		switch normalizePermissionResponse(prompt) {
-		case "/yes":
-			m.allowReadAll = true
-			m.denyReadAll = false
-			m.running = true
-			p := m.pendingPrompt
-			m.pendingPrompt = ""
-			m.appendAction(formatAction(ActionReadApproved, "session"))
-			m.appendUser(p)
-			if !m.thinkingActive {
-				m.appendSecondary("Thinking...")
-				m.thinkingActive = true
-			}
-			if len(m.pendingReadPaths) > 0 {
-				paths := m.pendingReadPaths
-				m.pendingReadPaths = nil
-				m.readRequestDepth = 0
-				m.patchReadRerun = false
-				m.patchFormatRetry = false
-				m.patchWriteRetry = false
-				m.lastReadPaths = paths
-				return startAgentStream(m, p, true, m.allowWriteAll && !m.denyWriteAll, paths)
-			}
-			m.patchReadRerun = false
-			m.patchFormatRetry = false
-			m.patchWriteRetry = false
-			return startAgentStream(m, p, true, m.allowWriteAll && !m.denyWriteAll, nil)
-		case "/always":
-			m.allowReadAll = true
-			m.denyReadAll = false
-			m.projectCfg.AllowReadAlways = true
-			if err := saveProjectConfig(m); err != nil {
-				m.appendAction(formatAction(ActionError, err.Error()))
-			}
-			m.running = true
-			p := m.pendingPrompt
-			m.pendingPrompt = ""
-			m.appendAction(formatAction(ActionReadAlways, ""))
-			m.appendUser(p)
-			if !m.thinkingActive {
-				m.appendSecondary("Thinking...")
-				m.thinkingActive = true
-			}
-			if len(m.pendingReadPaths) > 0 {
-				paths := m.pendingReadPaths
-				m.pendingReadPaths = nil
-				m.readRequestDepth = 0
-				m.patchReadRerun = false
-				m.patchFormatRetry = false
-				m.patchWriteRetry = false
-				m.lastReadPaths = paths
-				return startAgentStream(m, p, true, m.allowWriteAll && !m.denyWriteAll, paths)
-			}
-			m.patchReadRerun = false
-			m.patchFormatRetry = false
-			m.patchWriteRetry = false
-			return startAgentStream(m, p, true, m.allowWriteAll && !m.denyWriteAll, nil)
-		case "/no":
-			m.allowReadAll = false
-			m.denyReadAll = true
-			m.appendAction(formatAction(ActionReadDenied, "session"))
-			m.pendingPrompt = ""
-			m.pendingReadPaths = nil
-			m.readRequestDepth = 0
-			return nil
-		default:
-			m.appendPermission("READ REQUIRED. Choose an option:")
-			m.appendChoice("read", "Choose:", []string{"/yes allow for session", "/no deny for session", "/always always allow"})
-			return nil
-		}
+		return handleReadAnswer(m, normalizePermissionResponse(prompt))
 	}
 
 	if strings.HasPrefix(prompt, "/") {
 		cmd := strings.ToLower(strings.TrimSpace(prompt))
-		if cmd == "/yes" || cmd == "/no" || cmd == "/always" {
+		if cmd == "/yes" || cmd == "/no" || cmd == "/always" || cmd == "/never" {
 			m.appendAction(formatAction(ActionInfo, "No pending permission request"))
 			return nil
 		}
@@ -540,9 +742,13 @@ func submitPrompt(m *tuiModel, prompt string) tea.Cmd {
 			m.lastPrompt = ""
 			m.pendingPrompt = ""
 			m.pendingReadPaths = nil
+			m.pendingReads = nil
 			m.pendingWrites = nil
 			m.pendingDeletes = nil
 			m.pendingPatches = nil
+			m.pendingEdits = nil
+			m.pendingRefs = nil
+			m.pendingConflicts = nil
 			m.pendingPrefrontal = ""
 			m.readRequestDepth = 0
 			m.patchReadRerun = false
@@ -572,14 +778,38 @@ func submitPrompt(m *tuiModel, prompt string) tea.Cmd {
 		if cmd == "/apply" || cmd == "/apply-always" || cmd == "/deny" || cmd == "/deny-always" {
 			return handleApplyCommand(m, cmd)
 		}
+		if cmd == "/review" {
+			return startReview(m)
+		}
+		if strings.HasPrefix(cmd, "/resolve") {
+			return handleResolveCommand(m, prompt)
+		}
+		if cmd == "/undo" || cmd == "/checkpoints" || strings.HasPrefix(cmd, "/restore") {
+			return handleCheckpointCommand(m, prompt)
+		}
+		if cmd == "/diff" || cmd == "/commit" || strings.HasPrefix(cmd, "/commit ") {
+			return handleGitCommand(m, prompt)
+		}
+		if isMemoryCommand(cmd) {
+			return handleMemoryCommand(m, prompt)
+		}
+		if cmd == "/promote" || strings.HasPrefix(cmd, "/promote ") {
+			return handlePromoteCommand(m, prompt)
+		}
+		if cmd == "/sessions" || cmd == "/new" || cmd == "/resume" || strings.HasPrefix(cmd, "/resume ") {
+			return handleSessionCommand(m, prompt)
+		}
+		if cmd == "/export" || strings.HasPrefix(cmd, "/export ") {
+			return handleExportCommand(m, prompt)
+		}
+		if cmd == "/read" || strings.HasPrefix(cmd, "/read ") {
+			return handleReadCommand(m, prompt)
+		}
 		return runMemoryCmd(prompt)
 	}
 
 	mentions := agent.ExtractFileMentions(prompt)
-	if len(mentions) > 0 && !m.allowReadAll && !m.denyReadAll {
-		m.pendingPrompt = prompt
-		m.appendPermission("READ FILES? Choose an option:")
-		m.appendChoice("read", "Choose:", []string{"/yes allow for session", "/no deny for session", "/always always allow"})
+	if m.askRead("READ FILES? Choose an option:", prompt, mentions) {
 		return nil
 	}
 	m.running = true
@@ -601,12 +831,19 @@ func submitPrompt(m *tuiModel, prompt string) tea.Cmd {
 	return startAgentStream(m, prompt, m.allowReadAll, m.allowWriteAll && !m.denyWriteAll, nil)
 }
 
-func saveProjectConfig(m *tuiModel) error {
+// updateProjectConfig applies fn to the config on disk rather than to the
+// copy loaded at startup, and keeps the saved result.
+func updateProjectConfig(m *tuiModel, fn func(*agent.ProjectConfig)) error {
 	root, err := os.Getwd()
 	if err != nil {
 		return err
 	}
-	return agent.SaveProjectConfig(root, m.projectCfg)
+	cfg, err := agent.UpdateProjectConfig(root, fn)
+	if err != nil {
+		return err
+	}
+	m.projectCfg = cfg
+	return nil
 }
 
 func newMarkdownRenderer(width int) *glamour.TermRenderer {
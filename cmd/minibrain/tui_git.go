@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+type gitMsg struct {
+	action string
+	err    error
+}
+
+func handleGitCommand(m *tuiModel, prompt string) tea.Cmd {
+	root, err := os.Getwd()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	fields := strings.Fields(prompt)
+	switch strings.ToLower(fields[0]) {
+	case "/diff":
+		diff, err := agent.WorktreeDiff(root)
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		if strings.TrimSpace(diff) == "" {
+			m.appendAction(formatAction(ActionGit, "no uncommitted changes"))
+			return nil
+		}
+		const maxLines = 200
+		lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+		if len(lines) > maxLines {
+			lines = append(lines[:maxLines], "...")
+		}
+		m.appendPreview(formatPreviewBlock("DIFF", "worktree", lines))
+		return nil
+	case "/commit":
+		message := strings.TrimSpace(strings.TrimSpace(prompt)[len(fields[0]):])
+		m.running = true
+		m.status = "Committing"
+		cfg := applyConfig(root)
+		return func() tea.Msg {
+			res, err := agent.CommitAll(root, cfg, message)
+			if err != nil {
+				return gitMsg{err: err}
+			}
+			return gitMsg{action: formatAction(ActionGit, "COMMIT "+res.Commit+" "+agent.FirstLine(res.Message))}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+type memoryEditMsg struct {
+	path  string
+	tmp   string
+	scope agent.MemoryScope
+	err   error
+}
+
+func isMemoryCommand(cmd string) bool {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "/remember", "/memories", "/forget", "/memory":
+		return true
+	}
+	return false
+}
+
+func handleMemoryCommand(m *tuiModel, prompt string) tea.Cmd {
+	cfg, err := baseConfig()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	fields := strings.Fields(prompt)
+	var args []string
+	switch strings.ToLower(fields[0]) {
+	case "/remember":
+		args = append([]string{"add"}, fields[1:]...)
+	case "/memories":
+		args = append([]string{"list"}, fields[1:]...)
+	case "/forget":
+		args = append([]string{"forget"}, fields[1:]...)
+	default:
+		args = fields[1:]
+		if len(args) > 0 && strings.EqualFold(args[0], "edit") {
+			target := ""
+			if len(args) > 1 {
+				target = args[1]
+			}
+			path, tmp, scope, err := memoryEditTemp(cfg, target)
+			if err != nil {
+				m.appendAction(formatAction(ActionError, err.Error()))
+				return nil
+			}
+			return tea.ExecProcess(editorCommand(tmp), func(err error) tea.Msg {
+				return memoryEditMsg{path: path, tmp: tmp, scope: scope, err: err}
+			})
+		}
+		if len(args) == 0 {
+			m.appendAction(formatAction(ActionInfo, "Usage: /memory edit [neo|project|prefrontal] | log | revert <id> | history [ts] | restore <ts>"))
+			return nil
+		}
+	}
+	lines, err := memorySubcommand(cfg, args)
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	if len(args) > 1 && strings.EqualFold(args[0], "history") {
+		m.appendPreview(formatPreviewBlock("HISTORY", lines[0], lines[1:]))
+		return nil
+	}
+	for _, l := range lines {
+		m.appendAction(formatAction(ActionMemory, l))
+	}
+	m.refreshMemoryStats()
+	return nil
+}
+
+func (m *tuiModel) handleMemoryEdit(msg memoryEditMsg) {
+	if msg.err != nil {
+		_ = os.Remove(msg.tmp)
+		m.appendAction(formatAction(ActionError, "editor: "+msg.err.Error()))
+		return
+	}
+	cfg, err := baseConfig()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return
+	}
+	out, err := saveMemoryEdit(cfg, msg.path, msg.tmp, msg.scope)
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return
+	}
+	m.appendAction(formatAction(ActionMemory, out))
+	m.refreshMemoryStats()
+}
+
+func (m *tuiModel) refreshMemoryStats() {
+	if stats, err := initialStats(); err == nil {
+		m.stats = stats
+	}
+	m.usage = usageFromConfig()
+}
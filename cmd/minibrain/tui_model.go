@@ -16,6 +16,9 @@ import (
 const (
 	colorPrimary   = "252"
 	colorSecondary = "244"
+	colorAdded     = "71"
+	colorRemoved   = "167"
+	colorHunk      = "110"
 )
 
 type runMsg struct {
@@ -31,9 +34,9 @@ type memMsg struct {
 }
 
 type streamMsg struct {
-	done  bool
-	res   agent.Result
-	err   error
+	done bool
+	res  agent.Result
+	err  error
 }
 
 type historyEntry struct {
@@ -67,8 +70,15 @@ type tuiModel struct {
 	pendingWrites     []agent.WriteOp
 	pendingDeletes    []agent.DeleteOp
 	pendingPatches    []agent.PatchOp
+	pendingEdits      []agent.EditOp
+	pendingRefs       []agent.FileRef
+	pendingConflicts  []agent.OpResult
 	pendingPrefrontal string
 	pendingReadPaths  []string
+	pendingReads      []agent.ReadCandidate
+	readApprovals     agent.ReadApprovals
+	readSource        string
+	writeSource       string
 	readRequestDepth  int
 	patchReadRerun    bool
 	patchFormatRetry  bool
@@ -79,13 +89,13 @@ type tuiModel struct {
 	choiceActive      bool
 	choiceKind        string
 	choiceIndex       int
-	denyReadAll       bool
 	projectCfg        agent.ProjectConfig
 	mdRenderer        *glamour.TermRenderer
 	mdWidth           int
 	streamCh          chan streamMsg
 	showActions       bool
 	showRaw           bool
+	review            *reviewState
 }
 
 func runTUI() {
@@ -117,6 +127,8 @@ func newTUIModel() tuiModel {
 		allowReadAll:  perms.AllowRead,
 		allowWriteAll: perms.AllowWrite,
 		denyWriteAll:  perms.DenyWrite,
+		readSource:    perms.ReadSource,
+		writeSource:   perms.WriteSource,
 		model:         currentModel(),
 		choiceIndex:   0,
 		projectCfg:    perms.Project,
@@ -125,6 +137,9 @@ func newTUIModel() tuiModel {
 		status:        "Ready",
 	}
 	m.updateMarkdownRenderer()
+	if perms.Err != nil {
+		m.appendAction(formatAction(ActionError, perms.Err.Error()))
+	}
 	m.refreshViewport()
 	return m
 }
@@ -153,6 +168,10 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if m.review != nil {
+			return m, handleReviewKey(&m, msg)
+		}
+
 		if m.choiceActive {
 			switch msg.Type {
 			case tea.KeyUp:
@@ -225,29 +244,29 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.res = &msg.res
 		m.appendRaw(msg.res.LLMOutput)
-		readReq := msg.res.ReadRequests
-		if len(readReq) > 0 && m.denyReadAll {
-			m.appendAction(formatAction(ActionReadDenied, "session"))
-			m.appendRunResult(msg.res)
-			m.stats = msg.res.Memory
-			return m, nil
-		}
-		if len(readReq) > 0 && !m.allowReadAll {
-			m.pendingPrompt = m.lastPrompt
-			m.pendingReadPaths = readReq
-			m.status = "Ready"
-			m.appendPermission("READ REQUEST: can I read files in this directory?")
-			m.appendChoice("read", "Choose:", []string{"/yes allow for session", "/no deny for session", "/always always allow"})
-			return m, nil
+		if msg.res.AuditErr != nil {
+			m.appendAction(formatAction(ActionError, msg.res.AuditErr.Error()))
 		}
-		if len(readReq) > 0 && m.allowReadAll {
+		readReq := msg.res.ReadRequests
+		if len(readReq) > 0 {
+			if m.askRead("READ REQUEST: can I read these files?", m.lastPrompt, readReq) {
+				m.pendingReadPaths = readReq
+				return m, nil
+			}
+			approved := m.approvedReads(readReq)
+			if len(approved) == 0 {
+				m.appendAction(formatAction(ActionReadDenied, strings.Join(readReq, ", ")))
+				m.appendRunResult(msg.res)
+				m.stats = msg.res.Memory
+				return m, nil
+			}
 			if m.readRequestDepth >= 1 {
 				// avoid repeated read loops
 			} else {
 				m.readRequestDepth++
-				m.lastReadPaths = readReq
+				m.lastReadPaths = approved
 				m.running = true
-				return m, startAgentStream(&m, m.lastPrompt, true, m.allowWriteAll && !m.denyWriteAll, readReq)
+				return m, startAgentStream(&m, m.lastPrompt, m.allowReadAll, m.allowWriteAll && !m.denyWriteAll, approved)
 			}
 		}
 
@@ -275,21 +294,19 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			if len(patchPaths) > 0 {
-				if m.allowReadAll && !m.patchReadRerun {
-					m.patchReadRerun = true
-					m.appendAction("READ: " + strings.Join(patchPaths, ", "))
-					m.lastReadPaths = patchPaths
-					m.running = true
-					return m, startAgentStream(&m, m.lastPrompt, true, m.allowWriteAll && !m.denyWriteAll, patchPaths)
-				}
-				if !m.allowReadAll && !m.denyReadAll {
-					m.pendingPrompt = m.lastPrompt
-					m.pendingReadPaths = patchPaths
-					m.status = "Ready"
-					m.appendAction(formatAction(ActionReadRequest, "files needed for patches"))
-					m.appendPermission("READ FILES FOR PATCHES? Choose an option:")
-					m.appendChoice("read", "Choose:", []string{"/yes allow for session", "/no deny for session", "/always always allow"})
-					return m, nil
+				if !m.patchReadRerun {
+					if m.askRead("READ FILES FOR PATCHES? Choose an option:", m.lastPrompt, patchPaths) {
+						m.pendingReadPaths = patchPaths
+						m.appendAction(formatAction(ActionReadRequest, "files needed for patches"))
+						return m, nil
+					}
+					if approved := m.approvedReads(patchPaths); len(approved) > 0 {
+						m.patchReadRerun = true
+						m.appendAction("READ: " + strings.Join(approved, ", "))
+						m.lastReadPaths = approved
+						m.running = true
+						return m, startAgentStream(&m, m.lastPrompt, m.allowReadAll, m.allowWriteAll && !m.denyWriteAll, approved)
+					}
 				}
 			}
 		}
@@ -302,6 +319,17 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.appendRunResult(msg.res)
 		m.stats = msg.res.Memory
 		m.usage = usageFromConfig()
+		if conflicts := msg.res.Report.Conflicts(); len(conflicts) > 0 {
+			m.pendingWrites = msg.res.ProposedWrites
+			m.pendingDeletes = msg.res.ProposedDeletes
+			m.pendingPatches = msg.res.ProposedPatches
+			m.pendingEdits = msg.res.ProposedEdits
+			m.pendingRefs = msg.res.FileRefs
+			m.pendingPrefrontal = msg.res.PrefrontalPath
+			m.pendingPreviewed = true
+			m.offerConflictResolution(conflicts)
+			return m, nil
+		}
 		if len(msg.res.FailedPatches) > 0 && !m.patchWriteRetry {
 			var retryPaths []string
 			for _, p := range msg.res.FailedPatches {
@@ -310,23 +338,21 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			if len(retryPaths) > 0 {
-				if !m.allowReadAll && !m.denyReadAll {
-					m.pendingPrompt = m.lastPrompt
+				if m.askRead("READ FILES FOR FULL REWRITE? Choose an option:", m.lastPrompt, retryPaths) {
 					m.pendingReadPaths = retryPaths
-					m.status = "Ready"
-					m.appendPermission("READ FILES FOR FULL REWRITE? Choose an option:")
-					m.appendChoice("read", "Choose:", []string{"/yes allow for session", "/no deny for session", "/always always allow"})
 					return m, nil
 				}
 				m.patchWriteRetry = true
 				m.running = true
-				return m, startAgentStream(&m, patchRewritePrompt(m.lastPrompt, retryPaths), m.allowReadAll, m.allowWriteAll && !m.denyWriteAll, retryPaths)
+				return m, startAgentStream(&m, patchRewritePrompt(m.lastPrompt, retryPaths), m.allowReadAll, m.allowWriteAll && !m.denyWriteAll, m.approvedReads(retryPaths))
 			}
 		}
-		if !msg.res.Applied && (len(msg.res.ProposedWrites) > 0 || len(msg.res.ProposedDeletes) > 0 || len(msg.res.ProposedPatches) > 0) {
+		if !msg.res.Applied && (len(msg.res.ProposedWrites) > 0 || len(msg.res.ProposedDeletes) > 0 || len(msg.res.ProposedPatches) > 0 || len(msg.res.ProposedEdits) > 0) {
 			m.pendingWrites = msg.res.ProposedWrites
 			m.pendingDeletes = msg.res.ProposedDeletes
 			m.pendingPatches = msg.res.ProposedPatches
+			m.pendingEdits = msg.res.ProposedEdits
+			m.pendingRefs = msg.res.FileRefs
 			m.pendingPrefrontal = msg.res.PrefrontalPath
 			if !m.pendingPreviewed {
 				appendChangePreview(&m)
@@ -335,11 +361,11 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.denyWriteAll {
 				m.appendAction("CHANGES DENIED (always)")
 			} else if m.allowWriteAll {
-				return m, applyPending(&m, true)
+				return m, applyPending(&m, true, m.writeSource)
 			} else {
 				m.status = "Ready"
 				m.appendPermission("APPLY CHANGES? Choose an option:")
-				m.appendChoice("apply", "Choose:", []string{"/apply allow for session", "/apply-always always apply", "/deny deny for session", "/deny-always always deny"})
+				m.appendChoice("apply", "Choose:", applyOptions())
 			}
 		}
 		return m, nil
@@ -359,6 +385,23 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, func() tea.Msg { return msg2 }
 		}
 		return m, listenStream(m.streamCh)
+	case reviewEditMsg:
+		return m, applyReviewEdit(&m, msg)
+	case memoryEditMsg:
+		m.handleMemoryEdit(msg)
+		return m, nil
+	case promoteMsg:
+		m.handlePromoteMsg(msg)
+		return m, nil
+	case gitMsg:
+		m.running = false
+		m.status = "Ready"
+		if msg.err != nil {
+			m.appendAction(formatAction(ActionError, "git: "+msg.err.Error()))
+			return m, nil
+		}
+		m.appendAction(msg.action)
+		return m, nil
 	case memMsg:
 		m.running = false
 		if msg.err != nil {
@@ -373,6 +416,9 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else if msg.condensed {
 			m.appendAction(formatAction(ActionMemory, "CONDENSED"))
 		}
+		if msg.condensed {
+			m.notePendingPromotion()
+		}
 		return m, nil
 	}
 
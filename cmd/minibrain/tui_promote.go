@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+type promoteMsg struct {
+	promotion agent.Promotion
+	applied   bool
+	err       error
+	// background is set for the pass run when leaving a session, which only
+	// reports a summary instead of opening the review.
+	background bool
+}
+
+func handlePromoteCommand(m *tuiModel, prompt string) tea.Cmd {
+	cfg, err := baseConfig()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	fields := strings.Fields(strings.ToLower(prompt))
+	sub := ""
+	if len(fields) > 1 {
+		sub = fields[1]
+	}
+	switch sub {
+	case "accept":
+		added, removed, err := agent.AcceptPendingPromotion(cfg.BrainDir)
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		m.appendAction(formatAction(ActionMemory, fmt.Sprintf("PROMOTED +%d -%d", added, removed)))
+		m.refreshMemoryStats()
+		return nil
+	case "reject":
+		if err := agent.ClearPendingPromotion(cfg.BrainDir); err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		m.appendAction(formatAction(ActionMemory, "PROMOTION REJECTED"))
+		return nil
+	case "":
+		p, err := agent.LoadPendingPromotion(cfg.BrainDir)
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		if !p.Empty() {
+			m.showPromotion(p)
+			return nil
+		}
+		m.running = true
+		m.status = "Promoting"
+		return promoteCmd(cfg, false)
+	default:
+		m.appendAction(formatAction(ActionInfo, "Usage: /promote [accept|reject]"))
+		return nil
+	}
+}
+
+func promoteCmd(cfg agent.Config, background bool) tea.Cmd {
+	return func() tea.Msg {
+		p, applied, err := agent.PromoteShortTerm(cfg)
+		return promoteMsg{promotion: p, applied: applied, err: err, background: background}
+	}
+}
+
+func (m *tuiModel) handlePromoteMsg(msg promoteMsg) {
+	if !msg.background {
+		m.running = false
+		m.status = "Ready"
+	}
+	if msg.err != nil {
+		m.appendAction(formatAction(ActionError, "promote: "+msg.err.Error()))
+		return
+	}
+	if msg.applied {
+		m.appendAction(formatAction(ActionMemory, "PROMOTED "+agent.FormatPromotionSummary(msg.promotion)))
+		m.refreshMemoryStats()
+		return
+	}
+	if msg.background {
+		m.notePendingPromotion()
+		return
+	}
+	cfg, err := baseConfig()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return
+	}
+	p, _ := agent.LoadPendingPromotion(cfg.BrainDir)
+	if p.Empty() {
+		m.appendAction(formatAction(ActionMemory, "nothing new to promote"))
+		return
+	}
+	m.showPromotion(p)
+}
+
+func (m *tuiModel) showPromotion(p agent.Promotion) {
+	lines := strings.Split(strings.TrimRight(agent.PromotionDiff(p), "\n"), "\n")
+	m.appendPreview(formatPreviewBlock("PROMOTE", agent.FormatPromotionSummary(p), lines))
+	m.appendChoice("promote", "Update long-term memory?", []string{"/promote accept  Apply", "/promote reject  Discard"})
+}
+
+// notePendingPromotion points at /promote when a proposal is waiting.
+func (m *tuiModel) notePendingPromotion() {
+	cfg, err := baseConfig()
+	if err != nil {
+		return
+	}
+	if p, err := agent.LoadPendingPromotion(cfg.BrainDir); err == nil && !p.Empty() {
+		m.appendAction(formatAction(ActionMemory, "memory changes proposed ("+agent.FormatPromotionSummary(p)+"); /promote to review"))
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+// maxReadOptions is how many files get their own allow and deny choices in
+// a read prompt; longer lists are answered as a whole or with /read.
+const maxReadOptions = 4
+
+// readDecision reports whether path may be read and whether anything has
+// decided it yet. Per-path answers, for the session or saved, come first.
+func (m *tuiModel) readDecision(path string) (allowed, decided bool) {
+	approvals := m.readApprovals.Merge(m.projectCfg.ReadApprovals)
+	if allowed, decided := approvals.Decide(path); decided {
+		return allowed, true
+	}
+	return m.allowReadAll, m.allowReadAll
+}
+
+// askRead lists the requested files nobody has decided on yet, with their
+// sizes, and asks about them. It reports whether a prompt was shown.
+func (m *tuiModel) askRead(title, prompt string, paths []string) bool {
+	root, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	var pending []agent.ReadCandidate
+	for _, c := range agent.DescribeReads(root, paths) {
+		if c.Err != nil {
+			continue
+		}
+		if _, decided := m.readDecision(c.Path); !decided {
+			pending = append(pending, c)
+		}
+	}
+	if len(pending) == 0 {
+		return false
+	}
+	m.pendingPrompt = prompt
+	m.pendingReads = pending
+	m.status = "Ready"
+	m.showReadPrompt(title)
+	return true
+}
+
+func (m *tuiModel) showReadPrompt(title string) {
+	lines := []string{title}
+	for _, c := range m.pendingReads {
+		lines = append(lines, "  "+describeReadCandidate(c))
+	}
+	m.appendPermission(strings.Join(lines, "\n"))
+	m.appendChoice("read", "Choose:", readOptions(m.pendingReads))
+}
+
+func describeReadCandidate(c agent.ReadCandidate) string {
+	if c.Git {
+		return c.Path + " (git output)"
+	}
+	return c.Path + " " + formatBytes(int(c.Size))
+}
+
+func readOptions(pending []agent.ReadCandidate) []string {
+	opts := []string{
+		"/yes allow these for session",
+		"/no deny these for session",
+		"/always always allow these",
+		"/never never allow these",
+	}
+	if len(pending) < 2 || len(pending) > maxReadOptions {
+		return opts
+	}
+	for _, c := range pending {
+		opts = append(opts, "/read allow "+c.Path, "/read deny "+c.Path)
+	}
+	return opts
+}
+
+// approvedReads narrows requested paths to the ones the user allowed.
+// Paths that cannot be read anyway are kept so the model sees why.
+func (m *tuiModel) approvedReads(paths []string) []string {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, c := range agent.DescribeReads(root, paths) {
+		if c.Err != nil {
+			out = append(out, c.Mention)
+			continue
+		}
+		if allowed, _ := m.readDecision(c.Path); allowed {
+			out = append(out, c.Path)
+		}
+	}
+	return out
+}
+
+// decideRead records an answer for pattern, for the session or in the
+// project config.
+func (m *tuiModel) decideRead(pattern string, allow, always bool, command string) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	decision := "session "
+	if always {
+		decision = "always "
+	}
+	if allow {
+		decision += "allow"
+	} else {
+		decision += "deny"
+	}
+	// A decision that cannot be logged is not taken.
+	if err := agent.RecordPermission(root, auditInfo(command), "read", pattern, decision); err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	if !always {
+		m.readApprovals.Add(pattern, allow)
+		return nil
+	}
+	return updateProjectConfig(m, func(cfg *agent.ProjectConfig) {
+		cfg.ReadApprovals.Add(pattern, allow)
+	})
+}
+
+// handleReadAnswer applies /yes, /no, /always or /never to every listed
+// file, or a /read decision to the files it matches, then continues once
+// nothing is left undecided.
+func handleReadAnswer(m *tuiModel, resp string) tea.Cmd {
+	switch resp {
+	case "/yes", "/no", "/always", "/never":
+		allow := resp == "/yes" || resp == "/always"
+		always := resp == "/always" || resp == "/never"
+		var paths []string
+		for _, c := range m.pendingReads {
+			if err := m.decideRead(c.Path, allow, always, resp); err != nil {
+				m.appendAction(formatAction(ActionError, err.Error()))
+			}
+			paths = append(paths, c.Path)
+		}
+		m.appendAction(formatAction(readActionKind(allow, always), strings.Join(paths, ", ")))
+	default:
+		if !handleReadDecision(m, resp) {
+			m.appendPermission("READ REQUIRED. Choose an option:")
+			m.appendChoice("read", "Choose:", readOptions(m.pendingReads))
+			return nil
+		}
+	}
+
+	var left []agent.ReadCandidate
+	for _, c := range m.pendingReads {
+		if _, decided := m.readDecision(c.Path); !decided {
+			left = append(left, c)
+		}
+	}
+	m.pendingReads = left
+	if len(left) > 0 {
+		m.showReadPrompt("READ REQUEST: still undecided:")
+		return nil
+	}
+	return resumeRead(m)
+}
+
+// handleReadDecision handles `/read allow|deny <path|glob> [always]`. It
+// reports false when prompt is not one.
+func handleReadDecision(m *tuiModel, prompt string) bool {
+	fields := strings.Fields(prompt)
+	if len(fields) < 3 || len(fields) > 4 || strings.ToLower(fields[0]) != "/read" {
+		return false
+	}
+	verb := strings.ToLower(fields[1])
+	if verb != "allow" && verb != "deny" {
+		return false
+	}
+	always := len(fields) == 4 && strings.ToLower(fields[3]) == "always"
+	if len(fields) == 4 && !always {
+		return false
+	}
+	allow := verb == "allow"
+	if err := m.decideRead(fields[2], allow, always, "/read "+verb); err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+	}
+	m.appendAction(formatAction(readActionKind(allow, always), fields[2]))
+	return true
+}
+
+func readActionKind(allow, always bool) ActionKind {
+	switch {
+	case allow && always:
+		return ActionReadAlways
+	case allow:
+		return ActionReadApproved
+	case always:
+		return ActionReadNever
+	default:
+		return ActionReadDenied
+	}
+}
+
+// handleReadCommand handles /read outside a read prompt: on its own it lists
+// the current answers, otherwise it records one ahead of time.
+func handleReadCommand(m *tuiModel, prompt string) tea.Cmd {
+	if handleReadDecision(m, prompt) {
+		return nil
+	}
+	if len(strings.Fields(prompt)) > 1 {
+		m.appendAction(formatAction(ActionInfo, "Usage: /read [allow|deny <path|glob> [always]]"))
+		return nil
+	}
+	m.appendAction(formatAction(ActionInfo, "Read approvals"))
+	list := func(label string, patterns []string) {
+		if len(patterns) > 0 {
+			m.appendAction(label + ": " + strings.Join(patterns, ", "))
+		}
+	}
+	list("session allow", m.readApprovals.Allow)
+	list("session deny", m.readApprovals.Deny)
+	list("always allow", m.projectCfg.ReadApprovals.Allow)
+	list("never allow", m.projectCfg.ReadApprovals.Deny)
+	if m.allowReadAll {
+		m.appendAction("everything else: allowed")
+	}
+	return nil
+}
+
+// resumeRead runs the prompt that was waiting on read answers, with only the
+// approved files.
+func resumeRead(m *tuiModel) tea.Cmd {
+	p := m.pendingPrompt
+	paths := m.pendingReadPaths
+	m.pendingPrompt = ""
+	m.pendingReadPaths = nil
+	m.pendingReads = nil
+	m.readRequestDepth = 0
+	if len(paths) > 0 {
+		paths = m.approvedReads(paths)
+		if len(paths) == 0 {
+			return nil
+		}
+	} else if len(m.approvedReads(agent.ExtractFileMentions(p))) == 0 {
+		return nil
+	}
+	m.running = true
+	m.appendUser(p)
+	if !m.thinkingActive {
+		m.appendSecondary("Thinking...")
+		m.thinkingActive = true
+	}
+	if len(paths) > 0 {
+		m.lastReadPaths = paths
+	}
+	m.patchReadRerun = false
+	m.patchFormatRetry = false
+	m.patchWriteRetry = false
+	return startAgentStream(m, p, m.allowReadAll, m.allowWriteAll && !m.denyWriteAll, paths)
+}
@@ -62,7 +62,7 @@ func renderHistoryLine(h historyEntry, viewportWidth int, actionStyle, secondary
 		if h.bold {
 			bubbleStyle = bubbleStyle.Bold(true)
 		}
-		bubble := bubbleStyle.Render(h.text)
+		bubble := bubbleStyle.Render(colorizeDiff(h.text))
 		return lipgloss.NewStyle().Width(w).Align(lipgloss.Left).Render(secondaryStyle.Render(bubble))
 	case "raw":
 		bubbleStyle := lipgloss.NewStyle().Width(contentWidth).Align(lipgloss.Left)
@@ -90,6 +90,27 @@ func renderHistoryLine(h historyEntry, viewportWidth int, actionStyle, secondary
 	}
 }
 
+func colorizeDiff(text string) string {
+	added := lipgloss.NewStyle().Foreground(lipgloss.Color(colorAdded))
+	removed := lipgloss.NewStyle().Foreground(lipgloss.Color(colorRemoved))
+	header := lipgloss.NewStyle().Foreground(lipgloss.Color(colorHunk))
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		if i == 0 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(l, "@@"):
+			lines[i] = header.Render(l)
+		case strings.HasPrefix(l, "+"):
+			lines[i] = added.Render(l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = removed.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func actionLabel(text string) (string, string) {
 	trim := strings.TrimSpace(text)
 	label := ""
@@ -121,6 +142,8 @@ func actionLabel(text string) (string, string) {
 		return "Patch", body
 	case strings.HasPrefix(upper, "PATCH"):
 		return "Patch", body
+	case strings.HasPrefix(upper, "EDIT"):
+		return "Edit", body
 	case strings.HasPrefix(upper, "MODEL "):
 		return "Model", body
 	case strings.HasPrefix(upper, "MODEL"):
@@ -131,6 +154,16 @@ func actionLabel(text string) (string, string) {
 		return "Changes", body
 	case strings.HasPrefix(upper, "CHANGES"):
 		return "Changes", body
+	case strings.HasPrefix(upper, "SESSION"):
+		return "Session", body
+	case strings.HasPrefix(upper, "GIT"):
+		return "Git", body
+	case strings.HasPrefix(upper, "REVIEW"):
+		return "Review", body
+	case strings.HasPrefix(upper, "CONFLICT"):
+		return "Conflict", body
+	case strings.HasPrefix(upper, "CHECKPOINT"):
+		return "Checkpoint", body
 	case strings.HasPrefix(upper, "MEMORY "):
 		return "Memory", body
 	case strings.HasPrefix(upper, "MEMORY"):
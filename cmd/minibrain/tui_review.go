@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+type reviewState struct {
+	items []agent.ReviewItem
+	item  int
+	hunk  int
+}
+
+type reviewEditMsg struct {
+	path string
+	err  error
+}
+
+func startReview(m *tuiModel) tea.Cmd {
+	if len(m.pendingWrites) == 0 && len(m.pendingDeletes) == 0 && len(m.pendingPatches) == 0 && len(m.pendingEdits) == 0 {
+		m.appendAction(formatAction(ActionInfo, "No pending changes"))
+		return nil
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	// Edits are reviewed as whole-file writes, but the pending edits stay
+	// as they are until the review is finished, so a cancelled review
+	// still applies the model's edits rather than these snapshots.
+	writes := slices.Clone(m.pendingWrites)
+	if len(m.pendingEdits) > 0 {
+		edited, err := agent.EditsToWrites(root, m.pendingEdits)
+		if err != nil {
+			m.appendAction(formatAction(ActionEditFailed, err.Error()))
+			return nil
+		}
+		writes = append(writes, edited...)
+	}
+	var items []agent.ReviewItem
+	for _, item := range agent.BuildReview(root, writes, m.pendingDeletes, m.pendingPatches) {
+		if len(item.Hunks) > 0 {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		m.appendAction(formatAction(ActionInfo, "No hunks to review"))
+		return nil
+	}
+	m.review = &reviewState{items: items}
+	m.choiceActive = false
+	m.appendAction(formatAction(ActionReview, fmt.Sprintf("%d file(s)", len(items))))
+	m.appendAction(formatAction(ActionInfo, "y accept · n reject · e edit · a accept rest · q cancel"))
+	showReviewHunk(m)
+	return nil
+}
+
+func showReviewHunk(m *tuiModel) {
+	r := m.review
+	item := r.items[r.item]
+	h := item.Hunks[r.hunk]
+	title := fmt.Sprintf("%s %s [file %d/%d, hunk %d/%d]", item.Kind, item.Path, r.item+1, len(r.items), r.hunk+1, len(item.Hunks))
+	lines := h.Lines
+	if item.Kind != "DELETE" {
+		lines = append([]string{h.Header()}, h.Lines...)
+	}
+	m.appendPreview(formatPreviewBlock("REVIEW", title, lines))
+}
+
+func handleReviewKey(m *tuiModel, msg tea.KeyMsg) tea.Cmd {
+	r := m.review
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "y":
+		r.items[r.item].Hunks[r.hunk].Status = agent.HunkAccepted
+		return advanceReview(m)
+	case "n":
+		r.items[r.item].Hunks[r.hunk].Status = agent.HunkRejected
+		return advanceReview(m)
+	case "a":
+		for i := r.item; i < len(r.items); i++ {
+			for j := range r.items[i].Hunks {
+				if i == r.item && j < r.hunk {
+					continue
+				}
+				r.items[i].Hunks[j].Status = agent.HunkAccepted
+			}
+		}
+		return finishReview(m)
+	case "e":
+		if r.items[r.item].Kind == "DELETE" {
+			m.appendAction(formatAction(ActionInfo, "Deletes can only be accepted or rejected"))
+			return nil
+		}
+		return editReviewHunk(m)
+	case "q", "esc":
+		m.review = nil
+		m.appendAction(formatAction(ActionReview, "cancelled"))
+		m.appendChoice("apply", "Choose:", applyOptions())
+		return nil
+	}
+	return nil
+}
+
+func advanceReview(m *tuiModel) tea.Cmd {
+	r := m.review
+	r.hunk++
+	if r.hunk >= len(r.items[r.item].Hunks) {
+		r.item++
+		r.hunk = 0
+	}
+	if r.item >= len(r.items) {
+		return finishReview(m)
+	}
+	showReviewHunk(m)
+	return nil
+}
+
+func finishReview(m *tuiModel) tea.Cmd {
+	root, err := os.Getwd()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	items := m.review.items
+	m.review = nil
+	if m.pendingPrefrontal != "" {
+		agent.AppendPrefrontal(m.pendingPrefrontal, agent.FormatReviewSummary(items))
+	}
+	m.pendingWrites, m.pendingDeletes, m.pendingPatches = agent.ReviewedChanges(root, items)
+	m.pendingEdits = nil
+	m.appendAction(formatAction(ActionReview, "done"))
+	if len(m.pendingWrites) == 0 && len(m.pendingDeletes) == 0 && len(m.pendingPatches) == 0 && len(m.pendingEdits) == 0 {
+		m.appendAction(formatAction(ActionChangesDenied, "all hunks rejected"))
+		m.pendingRefs = nil
+		m.pendingPrefrontal = ""
+		m.pendingPreviewed = false
+		return nil
+	}
+	return applyPending(m, false, "/review")
+}
+
+func editReviewHunk(m *tuiModel) tea.Cmd {
+	r := m.review
+	item := r.items[r.item]
+	f, err := os.CreateTemp("", "minibrain-hunk-*"+filepath.Ext(item.Path))
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	_, err = f.WriteString(item.Hunks[r.hunk].NewSide() + "\n")
+	_ = f.Close()
+	if err != nil {
+		_ = os.Remove(f.Name())
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	path := f.Name()
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return reviewEditMsg{path: path, err: err}
+	})
+}
+
+func applyReviewEdit(m *tuiModel, msg reviewEditMsg) tea.Cmd {
+	defer func() { _ = os.Remove(msg.path) }()
+	if m.review == nil {
+		return nil
+	}
+	if msg.err != nil {
+		m.appendAction(formatAction(ActionError, "editor: "+msg.err.Error()))
+		return nil
+	}
+	b, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	r := m.review
+	r.items[r.item].Hunks[r.hunk] = agent.EditHunk(r.items[r.item].Hunks[r.hunk], string(b))
+	m.appendAction(formatAction(ActionReview, "hunk edited"))
+	return advanceReview(m)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
+
+func handleSessionCommand(m *tuiModel, prompt string) tea.Cmd {
+	cfg, err := baseConfig()
+	if err != nil {
+		m.appendAction(formatAction(ActionError, err.Error()))
+		return nil
+	}
+	fields := strings.Fields(prompt)
+	switch strings.ToLower(fields[0]) {
+	case "/sessions":
+		sessions, err := agent.ListSessions(cfg.BrainDir)
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		m.appendAction(formatAction(ActionSession, "Sessions"))
+		for i := len(sessions) - 1; i >= 0; i-- {
+			marker := "  "
+			if sessions[i].ID == cfg.SessionID {
+				marker = "* "
+			}
+			m.appendAction(marker + agent.FormatSession(sessions[i]))
+		}
+		return nil
+	case "/new":
+		s, err := startNewSession(cfg.RootDir, cfg.BrainDir)
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		switchSession(m)
+		m.appendAction(formatAction(ActionSession, "NEW "+s.ID))
+		return promoteCmd(cfg, true)
+	case "/resume":
+		if len(fields) < 2 {
+			m.appendAction(formatAction(ActionInfo, "Usage: /resume <id>"))
+			return nil
+		}
+		s, err := resumeSession(cfg.BrainDir, fields[1])
+		if err != nil {
+			m.appendAction(formatAction(ActionError, err.Error()))
+			return nil
+		}
+		switchSession(m)
+		m.appendAction(formatAction(ActionSession, "RESUMED "+agent.FormatSession(s)))
+		if s.ID == cfg.SessionID {
+			return nil
+		}
+		return promoteCmd(cfg, true)
+	}
+	return nil
+}
+
+// switchSession drops the on-screen conversation and any pending state that
+// belonged to the previous session.
+func switchSession(m *tuiModel) {
+	m.history = nil
+	m.viewport.SetContent("")
+	m.lastPrompt = ""
+	m.pendingPrompt = ""
+	m.pendingReadPaths = nil
+	m.pendingReads = nil
+	m.readApprovals = agent.ReadApprovals{}
+	m.pendingWrites = nil
+	m.pendingDeletes = nil
+	m.pendingPatches = nil
+	m.pendingEdits = nil
+	m.pendingRefs = nil
+	m.pendingConflicts = nil
+	m.pendingPrefrontal = ""
+	m.pendingPreviewed = false
+	m.review = nil
+	m.choiceActive = false
+	if stats, err := initialStats(); err == nil {
+		m.stats = stats
+	}
+	m.usage = usageFromConfig()
+}
@@ -1,6 +1,15 @@
 package main
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+)
 
 func TestNormalizePermissionResponse(t *testing.T) {
 	cases := map[string]string{
@@ -8,6 +17,7 @@ func TestNormalizePermissionResponse(t *testing.T) {
 		"/yes":   "/yes",
 		"always": "/always",
 		"no":     "/no",
+		"never":  "/never",
 		"maybe":  "maybe",
 		" /no ":  "/no",
 	}
@@ -21,3 +31,74 @@ func TestNormalizePermissionResponse(t *testing.T) {
 func TestMentionsReadInProse(t *testing.T) {
 	t.Skip("legacy prose read detection removed under strict JSON responses")
 }
+
+func TestParseExportArgs(t *testing.T) {
+	cases := []struct {
+		args         []string
+		format, path string
+	}{
+		{nil, "md", ""},
+		{[]string{"html"}, "html", ""},
+		{[]string{"json", "out.txt"}, "json", "out.txt"},
+		{[]string{"share/session.html"}, "html", "share/session.html"},
+	}
+	for _, c := range cases {
+		format, path, err := parseExportArgs(c.args)
+		if err != nil || format != c.format || path != c.path {
+			t.Fatalf("%v -> %q %q %v", c.args, format, path, err)
+		}
+	}
+	if _, _, err := parseExportArgs([]string{"md", "a", "b"}); err == nil {
+		t.Fatal("expected a usage error")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"24h":                  now.Add(-24 * time.Hour),
+		"7d":                   now.AddDate(0, 0, -7),
+		"2025-03-01T00:00:00Z": time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for in, want := range cases {
+		if got, err := parseSince(in, now); err != nil || !got.Equal(want) {
+			t.Fatalf("%q -> %v %v, want %v", in, got, err, want)
+		}
+	}
+	if got, err := parseSince("2025-03-01", now); err != nil || got.Day() != 1 {
+		t.Fatalf("date -> %v %v", got, err)
+	}
+	if _, err := parseSince("yesterday", now); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestBaseConfigDoesNotStartSession(t *testing.T) {
+	t.Setenv("MINIBRAIN_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	activeSessionID = ""
+	cfg, err := baseConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := agent.ListSessions(cfg.BrainDir); cfg.SessionID != "" || len(sessions) != 0 {
+		t.Fatalf("expected no session, got %q and %d sessions", cfg.SessionID, len(sessions))
+	}
+}
+
+func TestCancelledReviewKeepsEdits(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := &tuiModel{pendingEdits: []agent.EditOp{{Path: "a.txt", OldString: "one", NewString: "two"}}}
+	startReview(m)
+	if m.review == nil {
+		t.Fatal("expected a review to start")
+	}
+	handleReviewKey(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if m.review != nil || len(m.pendingEdits) != 1 || len(m.pendingWrites) != 0 {
+		t.Fatalf("expected the edit kept as an edit, got edits %v and writes %v", m.pendingEdits, m.pendingWrites)
+	}
+}
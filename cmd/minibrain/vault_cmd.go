@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chrishannah/minibrain/internal/agent"
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// runVaultCLI handles `minibrain vault [status|enable|disable]`.
+func runVaultCLI(args []string) error {
+	brainDir, err := agent.ResolveBrainDir()
+	if err != nil {
+		return fmt.Errorf("failed to resolve brain dir: %w", err)
+	}
+	sub := "status"
+	if len(args) > 0 {
+		sub = strings.ToLower(args[0])
+	}
+	switch sub {
+	case "status":
+		enabled, encrypted, total, err := vault.Status(brainDir)
+		if err != nil {
+			return err
+		}
+		if !enabled {
+			fmt.Printf("encryption: off (%d of %d files encrypted)\n", encrypted, total)
+			return nil
+		}
+		v, err := vault.Open(brainDir)
+		if err != nil {
+			fmt.Printf("encryption: on, locked (%v)\n", err)
+			return nil
+		}
+		fmt.Printf("encryption: on (%s), %d of %d files encrypted\n", v.KDF, encrypted, total)
+		return nil
+	case "enable":
+		v, err := vault.Create(brainDir)
+		if err != nil {
+			return err
+		}
+		n, err := vault.EncryptAll(brainDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("encryption enabled (%s); encrypted %d files\n", v.KDF, n)
+		return nil
+	case "disable":
+		n, err := vault.Disable(brainDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("encryption disabled; decrypted %d files\n", n)
+		return nil
+	}
+	return errors.New("usage: minibrain vault [status|enable|disable]")
+}
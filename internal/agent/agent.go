@@ -38,30 +38,63 @@ func Run(prompt string, cfg Config) (Result, error) {
 		cfg.TimeoutSec = 60
 	}
 
+	if _, err := NewRedactor(cfg.RedactPatterns); err != nil {
+		return Result{}, err
+	}
+	prompt = redactText(cfg, brainDir, "prompt", prompt)
+
 	neoPath := cfg.NeoPath
 	if neoPath == "" {
 		neoPath = filepath.Join(brainDir, "cortex", "NEO.md")
 	}
 
+	projectNeoPath := cfg.ProjectNeoPath
+	if projectNeoPath == "" {
+		projectNeoPath = ProjectNeoPath(root)
+	}
+
 	prefrontalPath := cfg.PrefrontalPath
 	if prefrontalPath == "" {
 		prefrontalPath = filepath.Join(brainDir, "cortex", "PREFRONTAL.md")
 	}
 
-	neo, err := readFileOrEmpty(neoPath)
+	contextPath := cfg.ContextPath
+	if contextPath == "" {
+		contextPath = filepath.Join(brainDir, "cortex", "CONTEXT.md")
+	}
+
+	if cfg.SessionID != "" {
+		if _, err := TouchSession(brainDir, cfg.SessionID, prompt); err != nil {
+			return Result{}, fmt.Errorf("failed to update session: %w", err)
+		}
+	}
+
+	neo, projectNeo, err := recallMemories(neoPath, projectNeoPath, prompt, cfg.MemoryTopK)
 	if err != nil {
-		return Result{}, fmt.Errorf("failed to read NEO.md: %w", err)
+		return Result{}, fmt.Errorf("failed to load long-term memory: %w", err)
 	}
 
+	start := time.Now()
 	agentConfig, _ := readFileOrEmpty(filepath.Join(brainDir, "MINIBRAIN.md"))
 	soul, _ := readFileOrEmpty(filepath.Join(brainDir, "SOUL.md"))
 
 	mentions := ExtractFileMentions(prompt)
-	fileRefs := LoadMentionedFiles(root, mentions, cfg.AllowReadAll, cfg.MaxFileBytes, cfg.MaxTotalReadBytes)
+	project, err := LoadProjectConfig(root)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to load project config: %w", err)
+	}
+	saved := project.ReadApprovals
+	fileRefs := loadFileRefs(root, mentions, approveRead(cfg, cfg.ReadApprovals.Merge(saved)), cfg.MaxFileBytes, cfg.MaxTotalReadBytes)
 	if len(cfg.ReadPaths) > 0 {
 		extra := LoadMentionedFiles(root, cfg.ReadPaths, true, cfg.MaxFileBytes, cfg.MaxTotalReadBytes)
 		fileRefs = MergeFileRefs(fileRefs, extra)
 	}
+	audit := AuditInfo{Session: cfg.SessionID, Model: cfg.Model}
+	// File content is only sent to the model once its read is on record.
+	if err := RecordReads(root, audit, fileRefs, readApprovalSource(cfg, saved)); err != nil {
+		return Result{}, fmt.Errorf("failed to write audit log: %w", err)
+	}
+	fileRefs = redactFileRefs(cfg, brainDir, fileRefs)
 	maxFiles := cfg.MaxFilesListed
 	if maxFiles <= 0 {
 		maxFiles = 2000
@@ -71,33 +104,50 @@ func Run(prompt string, cfg Config) (Result, error) {
 	if err := WritePrefrontalHeader(prefrontalPath, prompt, mentions, fileRefs); err != nil {
 		return Result{}, fmt.Errorf("failed to write PREFRONTAL.md: %w", err)
 	}
+	turn := TranscriptEvent{Type: EventTurn, Session: cfg.SessionID, Model: cfg.Model, Prompt: prompt, Mentions: mentions, Files: transcriptFiles(fileRefs)}
+	turn.Timings.LoadMs = sinceMs(start)
+	defer func() {
+		turn.Timings.TotalMs = sinceMs(start)
+		_ = AppendTranscript(TranscriptPath(prefrontalPath), turn)
+	}()
 
-	stmContext := buildShortTermContext(prefrontalPath, cfg.StmContextBytes)
-	convContext := loadConversationContext(brainDir, cfg.ConversationBytes)
-	devMsg := BuildDeveloperMessage(agentConfig, soul, neo, stmContext, convContext, prompt, fileRefs, fileList, truncated)
+	stmContext := buildShortTermContext(prefrontalPath, cfg.StmContextTokens)
+	convContext := loadConversationContext(contextPath, cfg.ConversationTokens)
+	devMsg := BuildDeveloperMessage(agentConfig, soul, neo, projectNeo, stmContext, convContext, prompt, fileRefs, fileList, truncated)
+	devMsg = redactText(cfg, brainDir, "developer message", devMsg)
+	turn.DeveloperHash = hashContent([]byte(devMsg))
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.TimeoutSec)*time.Second)
 	defer cancel()
 
-	llmOut, err := llm.CallOpenAI(ctx, cfg.Model, devMsg, prompt)
+	llmStart := time.Now()
+	llmOut, usage, err := llm.CallOpenAIUsage(ctx, cfg.Model, devMsg, prompt)
+	turn.Timings.LLMMs, turn.Usage = sinceMs(llmStart), usagePtr(usage)
 	if err != nil {
+		turn.Error = err.Error()
 		AppendPrefrontal(prefrontalPath, "\n## LLM Error\n"+err.Error()+"\n")
 		return Result{PrefrontalPath: prefrontalPath}, err
 	}
 
+	redactedOut := redactText(cfg, brainDir, "response", llmOut)
+	turn.RawOutput = redactedOut
+
 	structured, ok := ParseStructuredOutput(llmOut)
 	if !ok {
-		return Result{RawOutput: llmOut, PrefrontalPath: prefrontalPath}, errors.New("model returned invalid JSON response")
+		turn.Error = "model returned invalid JSON response"
+		return Result{RawOutput: llmOut, PrefrontalPath: prefrontalPath}, errors.New(turn.Error)
 	}
+	turn.Response = transcriptResponse(redactedOut)
 	message := structured.Message
 	readRequests := structured.Read
 	var proposedWrites []WriteOp
 	var proposedDeletes []DeleteOp
 	var proposedPatches []PatchOp
+	var proposedEdits []EditOp
 	for _, w := range structured.Writes {
 		if strings.TrimSpace(w.Path) == "" {
 			continue
 		}
-		proposedWrites = append(proposedWrites, WriteOp(w))
+		proposedWrites = append(proposedWrites, WriteOp{Path: w.Path, Content: w.Content})
 	}
 	for _, d := range structured.Deletes {
 		if strings.TrimSpace(d) == "" {
@@ -111,16 +161,36 @@ func Run(prompt string, cfg Config) (Result, error) {
 		}
 		proposedPatches = append(proposedPatches, PatchOp{Path: p.Path, Patch: p.Diff})
 	}
+	for _, e := range structured.Edits {
+		if strings.TrimSpace(e.Path) == "" {
+			continue
+		}
+		proposedEdits = append(proposedEdits, EditOp{Path: e.Path, OldString: e.OldString, NewString: e.NewString, ReplaceAll: e.ReplaceAll})
+	}
+	proposedWrites = AnnotateWriteStats(root, proposedWrites)
 	var appliedWrites []WriteOp
 	var appliedDeletes []DeleteOp
 	var appliedPatches []PatchOp
+	var appliedEdits []EditOp
 	var failedPatches []PatchFailure
 	var patchRetryPaths []string
+	var report ChangeReport
+	var auditErr error
 	applied := false
+	proposed := ChangeSet{Writes: proposedWrites, Deletes: proposedDeletes, Patches: proposedPatches, Edits: proposedEdits, Reads: fileRefs}
+	turn.Ops = proposedOps(proposed)
 	if cfg.ApplyWrites {
-		appliedWrites = ApplyWrites(root, proposedWrites)
-		appliedDeletes = ApplyDeletes(root, proposedDeletes)
-		appliedPatches, failedPatches = ApplyPatches(root, proposedPatches)
+		applyStart := time.Now()
+		report = ApplyChanges(root, proposed, cfg, prompt)
+		audit.Approval = cfg.WriteApproval
+		if err := RecordChanges(root, audit, report); err != nil {
+			auditErr = fmt.Errorf("failed to write audit log: %w", err)
+		}
+		appliedWrites = report.Writes
+		appliedDeletes = report.Deletes
+		appliedPatches = report.Patches
+		appliedEdits = report.Edits
+		failedPatches = report.PatchFailures()
 		if len(failedPatches) > 0 {
 			for _, f := range failedPatches {
 				if strings.TrimSpace(f.Path) != "" {
@@ -129,17 +199,30 @@ func Run(prompt string, cfg Config) (Result, error) {
 			}
 		}
 		applied = true
+		turn.Ops = reportOps(cfg, report)
+		turn.Timings.ApplyMs = sinceMs(applyStart)
 	}
 
-	AppendPrefrontal(prefrontalPath, "\n## LLM Output\n"+llmOut+"\n")
+	AppendPrefrontal(prefrontalPath, "\n## LLM Output\n"+redactedOut+"\n")
 	if applied {
 		AppendPrefrontal(prefrontalPath, FormatWritesSummary(appliedWrites))
 		AppendPrefrontal(prefrontalPath, FormatDeletesSummary(appliedDeletes))
 		AppendPrefrontal(prefrontalPath, FormatPatchesSummary(appliedPatches))
+		AppendPrefrontal(prefrontalPath, FormatEditsSummary(appliedEdits))
+		if summary := FormatNormalizedSummary(report); summary != "" {
+			AppendPrefrontal(prefrontalPath, summary)
+		}
+		if summary := FormatGitSummary(report.Git); summary != "" {
+			AppendPrefrontal(prefrontalPath, summary)
+		}
+		if !report.Committed {
+			AppendPrefrontal(prefrontalPath, FormatChangeReport(report))
+		}
 	} else {
 		AppendPrefrontal(prefrontalPath, FormatWritesSummaryWithTitle("Proposed Writes", proposedWrites))
 		AppendPrefrontal(prefrontalPath, FormatDeletesSummaryWithTitle("Proposed Deletes", proposedDeletes))
 		AppendPrefrontal(prefrontalPath, FormatPatchesSummaryWithTitle("Proposed Patches", proposedPatches))
+		AppendPrefrontal(prefrontalPath, FormatEditsSummaryWithTitle("Proposed Edits", proposedEdits))
 	}
 
 	condensed, err := AutoCondenseIfNeeded(cfg)
@@ -147,21 +230,25 @@ func Run(prompt string, cfg Config) (Result, error) {
 		AppendPrefrontal(prefrontalPath, "\n## Condense Error\n"+err.Error()+"\n")
 	}
 
-	appendConversationContext(brainDir, prompt, message, cfg.ConversationBytes)
+	appendConversationContext(contextPath, prompt, redactText(cfg, brainDir, "conversation", message), cfg.ConversationTokens)
 
-	stats, _ := GetMemoryStats(brainDir, neoPath, prefrontalPath)
+	stats, _ := GetMemoryStats(brainDir, neoPath, projectNeoPath, prefrontalPath)
 
 	return Result{
+		AuditErr:          auditErr,
 		LLMOutput:         message,
 		RawOutput:         llmOut,
 		Message:           message,
 		ProposedWrites:    proposedWrites,
 		ProposedDeletes:   proposedDeletes,
 		ProposedPatches:   proposedPatches,
+		ProposedEdits:     proposedEdits,
 		AppliedWrites:     appliedWrites,
 		AppliedDeletes:    appliedDeletes,
 		AppliedPatches:    appliedPatches,
+		AppliedEdits:      appliedEdits,
 		FailedPatches:     failedPatches,
+		Report:            report,
 		ReadRequests:      readRequests,
 		PatchRetryPaths:   patchRetryPaths,
 		Applied:           applied,
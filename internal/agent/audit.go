@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The audit log is an append-only record of every file minibrain reads or
+// changes in a project, and of every permission decision, kept in
+// .minibrain/audit.jsonl for review. Entries are never rewritten.
+const (
+	AuditRead       = "read"
+	AuditWrite      = "write"
+	AuditDelete     = "delete"
+	AuditPatch      = "patch"
+	AuditEdit       = "edit"
+	AuditRestore    = "restore"
+	AuditPermission = "permission"
+)
+
+// AuditEntry is one line of the audit log. Status is an Op* status for
+// reads as for changes. Reads fill only the before fields. Approval names
+// what allowed the action: a TUI command such as /apply or /always, a config
+// key, or an environment variable.
+type AuditEntry struct {
+	Time        string `json:"time"`
+	Session     string `json:"session,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Action      string `json:"action"`
+	Path        string `json:"path,omitempty"`
+	Status      string `json:"status,omitempty"`
+	BytesBefore int    `json:"bytes_before,omitempty"`
+	BytesAfter  int    `json:"bytes_after,omitempty"`
+	HashBefore  string `json:"sha256_before,omitempty"`
+	HashAfter   string `json:"sha256_after,omitempty"`
+	Approval    string `json:"approval,omitempty"`
+	Decision    string `json:"decision,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// AuditInfo is what a batch of entries is attributed to.
+type AuditInfo struct {
+	Session  string
+	Model    string
+	Approval string
+}
+
+// AuditFilter selects entries from the log. Path matches exactly or as a
+// glob, the same way path rules do.
+type AuditFilter struct {
+	Since time.Time
+	Path  string
+}
+
+func AuditPath(root string) string {
+	return filepath.Join(root, ".minibrain", "audit.jsonl")
+}
+
+func AppendAudit(root string, entries ...AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	var b strings.Builder
+	for _, e := range entries {
+		if e.Time == "" {
+			e.Time = now
+		}
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	path := AuditPath(root)
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return appendLocked(path, []byte(b.String()))
+}
+
+// LoadAudit reads the entries matching filter, oldest first, skipping lines
+// that do not parse.
+func LoadAudit(root string, filter AuditFilter) ([]AuditEntry, error) {
+	f, err := os.Open(AuditPath(root))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var out []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		if filter.match(e) {
+			out = append(out, e)
+		}
+	}
+	return out, scanner.Err()
+}
+
+func (f AuditFilter) match(e AuditEntry) bool {
+	if !f.Since.IsZero() {
+		t, err := time.Parse(time.RFC3339Nano, e.Time)
+		if err != nil || t.Before(f.Since) {
+			return false
+		}
+	}
+	if f.Path == "" {
+		return true
+	}
+	want := filepath.ToSlash(filepath.Clean(f.Path))
+	if e.Path == want {
+		return true
+	}
+	ok, _ := matchRule(want, e.Path)
+	return ok
+}
+
+// RecordReads logs the files loaded for a turn, including ones that were
+// denied or failed to load.
+func RecordReads(root string, info AuditInfo, refs []FileRef, approval func(rel string) string) error {
+	var entries []AuditEntry
+	for _, r := range refs {
+		e := AuditEntry{Session: info.Session, Model: info.Model, Action: AuditRead, Path: filepath.ToSlash(r.Path), Status: OpApplied}
+		if r.Err != nil {
+			e.Status = OpFailed
+			e.Reason = r.Err.Error()
+		} else {
+			e.BytesBefore = len(r.Content)
+			e.HashBefore = r.Hash
+			e.Approval = approval(e.Path)
+		}
+		entries = append(entries, e)
+	}
+	return AppendAudit(root, entries...)
+}
+
+// RecordChanges logs each op of an applied or rejected change set.
+func RecordChanges(root string, info AuditInfo, report ChangeReport) error {
+	var entries []AuditEntry
+	for _, res := range report.Results {
+		entries = append(entries, AuditEntry{
+			Session:     info.Session,
+			Model:       info.Model,
+			Action:      strings.ToLower(res.Kind),
+			Path:        filepath.ToSlash(res.Path),
+			Status:      res.Status,
+			BytesBefore: res.BytesBefore,
+			BytesAfter:  res.BytesAfter,
+			HashBefore:  res.HashBefore,
+			HashAfter:   res.HashAfter,
+			Approval:    info.Approval,
+			Reason:      res.Reason,
+		})
+	}
+	return AppendAudit(root, entries...)
+}
+
+// RecordRestore logs the files put back by restoring a checkpoint.
+func RecordRestore(root string, info AuditInfo, cp Checkpoint) error {
+	var entries []AuditEntry
+	for _, f := range cp.Files {
+		e := AuditEntry{Session: info.Session, Model: info.Model, Action: AuditRestore, Path: filepath.ToSlash(f.Path), Status: OpApplied, Approval: info.Approval, Reason: "checkpoint " + cp.ID}
+		if b, err := os.ReadFile(filepath.Join(root, f.Path)); err == nil {
+			e.BytesAfter = len(b)
+			e.HashAfter = hashContent(b)
+		}
+		entries = append(entries, e)
+	}
+	return AppendAudit(root, entries...)
+}
+
+// RecordPermission logs a permission decision. Path is the file or glob it
+// covers, or empty for a session-wide decision.
+func RecordPermission(root string, info AuditInfo, scope, path, decision string) error {
+	return AppendAudit(root, AuditEntry{
+		Session:  info.Session,
+		Model:    info.Model,
+		Action:   AuditPermission,
+		Path:     path,
+		Approval: info.Approval,
+		Decision: scope + " " + decision,
+	})
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditRecordsChanges(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info := AuditInfo{Session: "s1", Model: "gpt-4.1", Approval: "/apply"}
+
+	refs := LoadMentionedFiles(root, []string{"a.txt"}, true, 0, 0)
+	refs = append(refs, FileRef{Mention: "gone.txt", Path: "gone.txt", Err: errors.New("not found")})
+	if err := RecordReads(root, info, refs, func(string) string { return "MINIBRAIN_ALLOW_READ" }); err != nil {
+		t.Fatal(err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{
+		Writes: []WriteOp{{Path: "b.txt", Content: "new\n"}},
+		Edits:  []EditOp{{Path: "a.txt", OldString: "one", NewString: "two"}},
+	})
+	if !report.Committed {
+		t.Fatalf("apply failed: %v", report.Err)
+	}
+	if err := RecordChanges(root, info, report); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordPermission(root, info, "write", "", "session allow"); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := LoadAudit(root, AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("expected 5 entries, got %+v", all)
+	}
+	read := all[0]
+	if read.Action != AuditRead || read.BytesBefore != 4 || read.HashBefore != hashContent([]byte("one\n")) || read.Approval != "MINIBRAIN_ALLOW_READ" {
+		t.Fatalf("unexpected read entry %+v", read)
+	}
+	if all[1].Status != OpFailed || all[1].Reason == "" {
+		t.Fatalf("expected the missing file to be logged as failed, got %+v", all[1])
+	}
+
+	edits, err := LoadAudit(root, AuditFilter{Path: "a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 2 {
+		t.Fatalf("expected the read and the edit of a.txt, got %+v", edits)
+	}
+	edit := edits[1]
+	if edit.Action != AuditEdit || edit.HashBefore != hashContent([]byte("one\n")) || edit.HashAfter != hashContent([]byte("two\n")) || edit.Approval != "/apply" || edit.Session != "s1" {
+		t.Fatalf("unexpected edit entry %+v", edit)
+	}
+	if got, _ := LoadAudit(root, AuditFilter{Path: "*.txt"}); len(got) != 4 {
+		t.Fatalf("expected a glob to match every file entry, got %d", len(got))
+	}
+	if got, _ := LoadAudit(root, AuditFilter{Since: time.Now().Add(time.Hour)}); len(got) != 0 {
+		t.Fatalf("expected nothing newer than an hour from now, got %d", len(got))
+	}
+}
+
+func TestAuditAppendFailure(t *testing.T) {
+	root := t.TempDir()
+	// A directory where the log should be makes every append fail.
+	if err := os.MkdirAll(AuditPath(root), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordPermission(root, AuditInfo{}, "read", "a.txt", "session allow"); err == nil {
+		t.Fatal("expected the append to fail")
+	}
+}
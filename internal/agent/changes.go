@@ -2,8 +2,6 @@ package agent
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -123,75 +121,11 @@ func ParsePatchBlocks(s string) []PatchOp {
 	return patches
 }
 
-func ApplyWrites(root string, writes []WriteOp) []WriteOp {
-	var applied []WriteOp
-	for _, w := range writes {
-		clean, err := safeRelPath(w.Path)
-		if err != nil {
-			continue
-		}
-		p := filepath.Join(root, clean)
-		if err := ensureDir(filepath.Dir(p)); err != nil {
-			continue
-		}
-		if err := os.WriteFile(p, []byte(w.Content), 0644); err != nil {
-			continue
-		}
-		applied = append(applied, WriteOp{Path: clean, Content: w.Content})
-	}
-	return applied
-}
-
-func ApplyDeletes(root string, deletes []DeleteOp) []DeleteOp {
-	var applied []DeleteOp
-	for _, d := range deletes {
-		clean, err := safeRelPath(d.Path)
-		if err != nil {
-			continue
-		}
-		p := filepath.Join(root, clean)
-		if err := os.Remove(p); err != nil {
-			continue
-		}
-		applied = append(applied, DeleteOp{Path: clean})
-	}
-	return applied
-}
-
 type PatchFailure struct {
 	Path   string
 	Reason string
 }
 
-func ApplyPatches(root string, patches []PatchOp) ([]PatchOp, []PatchFailure) {
-	var applied []PatchOp
-	var failed []PatchFailure
-	for _, p := range patches {
-		clean, err := safeRelPath(p.Path)
-		if err != nil {
-			failed = append(failed, PatchFailure{Path: p.Path, Reason: "invalid path"})
-			continue
-		}
-		abs := filepath.Join(root, clean)
-		b, err := os.ReadFile(abs)
-		if err != nil {
-			failed = append(failed, PatchFailure{Path: clean, Reason: "read failed: " + err.Error()})
-			continue
-		}
-		updated, ok := applyUnifiedPatch(string(b), p.Patch)
-		if !ok {
-			failed = append(failed, PatchFailure{Path: clean, Reason: "patch failed to apply"})
-			continue
-		}
-		if err := os.WriteFile(abs, []byte(updated), 0644); err != nil {
-			failed = append(failed, PatchFailure{Path: clean, Reason: "write failed: " + err.Error()})
-			continue
-		}
-		applied = append(applied, PatchOp{Path: clean, Patch: p.Patch})
-	}
-	return applied, failed
-}
-
 func FormatWritesSummary(writes []WriteOp) string {
 	return FormatWritesSummaryWithTitle("Writes", writes)
 }
@@ -204,6 +138,10 @@ func FormatPatchesSummary(patches []PatchOp) string {
 	return FormatPatchesSummaryWithTitle("Patches", patches)
 }
 
+func FormatEditsSummary(edits []EditOp) string {
+	return FormatEditsSummaryWithTitle("Edits", edits)
+}
+
 func FormatWritesSummaryWithTitle(title string, writes []WriteOp) string {
 	if len(writes) == 0 {
 		return "\n## " + title + "\n(none)\n"
@@ -211,7 +149,7 @@ func FormatWritesSummaryWithTitle(title string, writes []WriteOp) string {
 	var b strings.Builder
 	b.WriteString("\n## " + title + "\n")
 	for _, w := range writes {
-		b.WriteString("- " + w.Path + " (" + fmt.Sprintf("%d", len(w.Content)) + " bytes)\n")
+		b.WriteString("- " + w.Path + " (" + fmt.Sprintf("%d bytes, +%d -%d", len(w.Content), w.Added, w.Removed) + ")\n")
 	}
 	return b.String()
 }
@@ -239,3 +177,19 @@ func FormatPatchesSummaryWithTitle(title string, patches []PatchOp) string {
 	}
 	return b.String()
 }
+
+func FormatEditsSummaryWithTitle(title string, edits []EditOp) string {
+	if len(edits) == 0 {
+		return "\n## " + title + "\n(none)\n"
+	}
+	var b strings.Builder
+	b.WriteString("\n## " + title + "\n")
+	for _, e := range edits {
+		line := "- " + e.Path + fmt.Sprintf(" (-%d +%d lines", len(splitLines(e.OldString)), len(splitLines(e.NewString)))
+		if e.ReplaceAll {
+			line += ", all occurrences"
+		}
+		b.WriteString(line + ")\n")
+	}
+	return b.String()
+}
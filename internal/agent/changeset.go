@@ -0,0 +1,525 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	OpApplied    = "applied"
+	OpFailed     = "failed"
+	OpRolledBack = "rolled back"
+	OpSkipped    = "skipped"
+	OpConflict   = "conflict"
+)
+
+type ChangeSet struct {
+	Writes  []WriteOp
+	Deletes []DeleteOp
+	Patches []PatchOp
+	Edits   []EditOp
+	Reads   []FileRef
+}
+
+type OpResult struct {
+	Kind        string
+	Path        string
+	Status      string
+	Reason      string
+	Merged      string
+	Theirs      string
+	Diff        string
+	Conflicts   int
+	Normalized  string
+	BytesBefore int
+	BytesAfter  int
+	HashBefore  string
+	HashAfter   string
+}
+
+type ChangeReport struct {
+	Results    []OpResult
+	Writes     []WriteOp
+	Deletes    []DeleteOp
+	Patches    []PatchOp
+	Edits      []EditOp
+	Checkpoint string
+	Committed  bool
+	Err        error
+	Git        *GitResult
+}
+
+func (s ChangeSet) Empty() bool {
+	return len(s.Writes) == 0 && len(s.Deletes) == 0 && len(s.Patches) == 0 && len(s.Edits) == 0
+}
+
+func (r ChangeReport) Conflicts() []OpResult {
+	var out []OpResult
+	for _, res := range r.Results {
+		if res.Status == OpConflict {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+func (r ChangeReport) PatchFailures() []PatchFailure {
+	var out []PatchFailure
+	for _, res := range r.Results {
+		if res.Kind == "PATCH" && res.Status == OpFailed {
+			out = append(out, PatchFailure{Path: res.Path, Reason: res.Reason})
+		}
+	}
+	return out
+}
+
+// stagedFile tracks one path through validate, stage and commit so that a
+// failure at any point can put the original bytes back.
+type stagedFile struct {
+	rel     string
+	abs     string
+	existed bool
+	orig    []byte
+	mode    fs.FileMode
+	content []byte
+	data    []byte
+	deleted bool
+	ops     []int
+	tmp     string
+	done    bool
+	stale   bool
+	base    *FileRef
+	theirs  []byte
+	format  textFormat
+	notes   []string
+}
+
+type changeOp struct {
+	kind  string
+	path  string
+	write *WriteOp
+	patch *PatchOp
+	edit  *EditOp
+}
+
+// ApplyChangeSet validates every op, stages new content in temp files and
+// renames them into place; any failure restores the files already committed.
+func ApplyChangeSet(root string, set ChangeSet) ChangeReport {
+	set = restoreSecrets(set)
+	var ops []changeOp
+	for i := range set.Writes {
+		ops = append(ops, changeOp{kind: "WRITE", path: set.Writes[i].Path, write: &set.Writes[i]})
+	}
+	for i := range set.Deletes {
+		ops = append(ops, changeOp{kind: "DELETE", path: set.Deletes[i].Path})
+	}
+	for i := range set.Patches {
+		ops = append(ops, changeOp{kind: "PATCH", path: set.Patches[i].Path, patch: &set.Patches[i]})
+	}
+	for i := range set.Edits {
+		ops = append(ops, changeOp{kind: "EDIT", path: set.Edits[i].Path, edit: &set.Edits[i]})
+	}
+
+	report := ChangeReport{Results: make([]OpResult, len(ops))}
+	for i, op := range ops {
+		report.Results[i] = OpResult{Kind: op.kind, Path: op.path, Status: OpSkipped}
+	}
+	if len(ops) == 0 {
+		report.Committed = true
+		return report
+	}
+
+	bases := map[string]FileRef{}
+	for _, ref := range set.Reads {
+		if ref.Err != nil || ref.Hash == "" {
+			continue
+		}
+		if clean, err := safeRelPath(ref.Path); err == nil {
+			bases[clean] = ref
+		}
+	}
+
+	files := map[string]*stagedFile{}
+	var order []string
+	failed := false
+	rules, rulesErr := LoadPathRules(root)
+	for i, op := range ops {
+		if rulesErr != nil {
+			report.Results[i].Status = OpFailed
+			report.Results[i].Reason = rulesErr.Error()
+			failed = true
+			continue
+		}
+		clean, err := safeRelPath(op.path)
+		if err != nil {
+			report.Results[i].Status = OpFailed
+			report.Results[i].Reason = "invalid path: " + err.Error()
+			failed = true
+			continue
+		}
+		report.Results[i].Path = clean
+		if err := rules.checkOp(op.kind, clean); err != nil {
+			report.Results[i].Status = OpFailed
+			report.Results[i].Reason = err.Error()
+			failed = true
+			continue
+		}
+		f, ok := files[clean]
+		if !ok {
+			f, err = loadStagedFile(root, clean)
+			if err != nil {
+				report.Results[i].Status = OpFailed
+				report.Results[i].Reason = err.Error()
+				failed = true
+				continue
+			}
+			files[clean] = f
+			order = append(order, clean)
+			if base, ok := bases[clean]; ok && (!f.existed || hashContent(f.orig) != base.Hash) {
+				f.stale = true
+				f.base = &base
+				f.theirs = []byte(f.format.decode(base.Content))
+			}
+		}
+		if f.stale && (!f.existed || op.kind == "DELETE") {
+			report.Results[i].Status = OpConflict
+			report.Results[i].Reason = "file changed on disk since it was read"
+			failed = true
+			continue
+		}
+		if err := stageOp(f, op); err != nil {
+			report.Results[i].Status = OpFailed
+			report.Results[i].Reason = err.Error()
+			failed = true
+			continue
+		}
+		f.ops = append(f.ops, i)
+	}
+	for _, rel := range order {
+		f := files[rel]
+		if !f.stale || len(f.ops) == 0 {
+			continue
+		}
+		merged, conflicts := Merge3(f.format.decode(f.base.Content), f.format.decode(string(f.orig)), string(f.theirs))
+		if conflicts > 0 {
+			for _, i := range f.ops {
+				report.Results[i].Status = OpConflict
+				report.Results[i].Reason = fmt.Sprintf("file changed on disk since it was read; %d conflicting region(s)", conflicts)
+				report.Results[i].Merged = merged
+				report.Results[i].Theirs = string(f.theirs)
+				report.Results[i].Conflicts = conflicts
+			}
+			failed = true
+			continue
+		}
+		f.content = []byte(merged)
+		for _, i := range f.ops {
+			report.Results[i].Reason = "merged with on-disk edits"
+		}
+	}
+	if failed {
+		report.Err = errors.New("change set validation failed")
+		if len(report.Conflicts()) > 0 {
+			report.Err = errors.New("files changed on disk since they were read")
+		}
+		return report
+	}
+
+	sort.Strings(order)
+	cp, err := CreateCheckpoint(root, order)
+	if err != nil {
+		report.Err = fmt.Errorf("failed to create checkpoint: %w", err)
+		return report
+	}
+	report.Checkpoint = cp.ID
+
+	var createdDirs []string
+	cleanup := func() {
+		for _, rel := range order {
+			if f := files[rel]; f.tmp != "" {
+				_ = os.Remove(f.tmp)
+			}
+		}
+		removeEmptyDirs(createdDirs)
+		_ = DeleteCheckpoint(root, cp.ID)
+		report.Checkpoint = ""
+	}
+
+	for _, rel := range order {
+		f := files[rel]
+		if f.deleted {
+			continue
+		}
+		dirs, err := mkdirAllTracked(filepath.Dir(f.abs))
+		createdDirs = append(createdDirs, dirs...)
+		if err != nil {
+			markFailed(&report, f.ops, "stage failed: "+err.Error())
+			cleanup()
+			report.Err = fmt.Errorf("failed to stage %s: %w", rel, err)
+			return report
+		}
+		data, notes := f.format.encode(string(f.content))
+		f.data = data
+		f.notes = notes
+		tmp, err := writeTemp(f.abs, data, f.mode)
+		if err != nil {
+			markFailed(&report, f.ops, "stage failed: "+err.Error())
+			cleanup()
+			report.Err = fmt.Errorf("failed to stage %s: %w", rel, err)
+			return report
+		}
+		f.tmp = tmp
+	}
+
+	for _, rel := range order {
+		f := files[rel]
+		var err error
+		if f.deleted {
+			if f.existed {
+				err = os.Remove(f.abs)
+			}
+		} else {
+			err = os.Rename(f.tmp, f.abs)
+			if err == nil {
+				f.tmp = ""
+			}
+		}
+		if err != nil {
+			markFailed(&report, f.ops, "commit failed: "+err.Error())
+			rollbackStaged(files, order)
+			cleanup()
+			for i := range report.Results {
+				if report.Results[i].Status == OpSkipped {
+					report.Results[i].Status = OpRolledBack
+				}
+			}
+			report.Err = fmt.Errorf("failed to commit %s: %w", rel, err)
+			return report
+		}
+		f.done = true
+	}
+
+	diffed := map[string]bool{}
+	for i, op := range ops {
+		report.Results[i].Status = OpApplied
+		path := report.Results[i].Path
+		f := files[path]
+		// A file changed by several ops carries its diff on the first.
+		if !diffed[path] {
+			diffed[path] = true
+			var before, after string
+			if f.existed {
+				before = f.format.decode(string(f.orig))
+			}
+			if !f.deleted {
+				after = f.format.decode(string(f.data))
+			}
+			report.Results[i].Diff = UnifiedDiff(path, before, after, 3)
+		}
+		report.Results[i].Normalized = strings.Join(f.notes, ", ")
+		if f.existed {
+			report.Results[i].BytesBefore = len(f.orig)
+			report.Results[i].HashBefore = hashContent(f.orig)
+		}
+		if !f.deleted {
+			report.Results[i].BytesAfter = len(f.data)
+			report.Results[i].HashAfter = hashContent(f.data)
+		}
+		switch op.kind {
+		case "WRITE":
+			added, removed := DiffStats(f.format.decode(string(f.orig)), f.format.decode(op.write.Content))
+			report.Writes = append(report.Writes, WriteOp{Path: path, Content: op.write.Content, Added: added, Removed: removed})
+		case "DELETE":
+			report.Deletes = append(report.Deletes, DeleteOp{Path: path})
+		case "PATCH":
+			report.Patches = append(report.Patches, PatchOp{Path: path, Patch: op.patch.Patch})
+		case "EDIT":
+			e := *op.edit
+			e.Path = path
+			report.Edits = append(report.Edits, e)
+		}
+	}
+	report.Committed = true
+	return report
+}
+
+func loadStagedFile(root, rel string) (*stagedFile, error) {
+	abs := filepath.Join(root, rel)
+	f := &stagedFile{rel: rel, abs: abs, mode: 0644}
+	info, err := os.Stat(abs)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return f, nil
+		}
+		return nil, errors.New("stat failed: " + err.Error())
+	}
+	if info.IsDir() {
+		return nil, errors.New("path is a directory")
+	}
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, errors.New("read failed: " + err.Error())
+	}
+	f.existed = true
+	f.orig = b
+	f.format = detectTextFormat(b)
+	f.content = []byte(f.format.decode(string(b)))
+	f.mode = info.Mode().Perm()
+	return f, nil
+}
+
+// stageOp applies op to the staged content. For stale files the model's ops
+// are replayed on the read snapshot instead, ready for a three-way merge.
+func stageOp(f *stagedFile, op changeOp) error {
+	content := &f.content
+	if f.stale {
+		content = &f.theirs
+	}
+	switch op.kind {
+	case "WRITE":
+		*content = []byte(op.write.Content)
+		f.deleted = false
+	case "DELETE":
+		if f.deleted || (!f.existed && f.content == nil) {
+			return errors.New("file does not exist")
+		}
+		f.content = nil
+		f.deleted = true
+	case "PATCH":
+		if f.deleted || (!f.existed && f.content == nil) {
+			return errors.New("file does not exist")
+		}
+		updated, ok := applyUnifiedPatch(string(*content), op.patch.Patch)
+		if !ok {
+			return errors.New("patch failed to apply")
+		}
+		*content = []byte(updated)
+	case "EDIT":
+		if f.deleted || (!f.existed && f.content == nil) {
+			return errors.New("file does not exist")
+		}
+		updated, err := ApplyEdit(string(*content), *op.edit)
+		if err != nil {
+			return err
+		}
+		*content = []byte(updated)
+	}
+	return nil
+}
+
+func markFailed(report *ChangeReport, ops []int, reason string) {
+	for _, i := range ops {
+		report.Results[i].Status = OpFailed
+		report.Results[i].Reason = reason
+	}
+}
+
+func rollbackStaged(files map[string]*stagedFile, order []string) {
+	for i := len(order) - 1; i >= 0; i-- {
+		f := files[order[i]]
+		if !f.done {
+			continue
+		}
+		if f.existed {
+			if tmp, err := writeTemp(f.abs, f.orig, f.mode); err == nil {
+				if err := os.Rename(tmp, f.abs); err != nil {
+					_ = os.Remove(tmp)
+				}
+			}
+			continue
+		}
+		_ = os.Remove(f.abs)
+	}
+}
+
+func writeTemp(target string, content []byte, mode fs.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".minibrain-*")
+	if err != nil {
+		return "", err
+	}
+	name := tmp.Name()
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(name)
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(name)
+		return "", err
+	}
+	if err := os.Chmod(name, mode); err != nil {
+		_ = os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+func mkdirAllTracked(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+	if err := ensureDir(dir); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+func removeEmptyDirs(dirs []string) {
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, d := range dirs {
+		_ = os.Remove(d)
+	}
+}
+
+func FormatChangeReport(report ChangeReport) string {
+	var b strings.Builder
+	b.WriteString("\n## Change Set\n")
+	if len(report.Results) == 0 {
+		b.WriteString("(none)\n")
+		return b.String()
+	}
+	for _, r := range report.Results {
+		line := "- " + r.Kind + " " + r.Path + ": " + r.Status
+		if r.Reason != "" {
+			line += " (" + r.Reason + ")"
+		}
+		b.WriteString(line + "\n")
+	}
+	if report.Checkpoint != "" {
+		b.WriteString("- Checkpoint: " + report.Checkpoint + "\n")
+	}
+	if !report.Committed {
+		b.WriteString("- Result: rolled back, no files changed\n")
+	}
+	return b.String()
+}
+
+// FormatNormalizedSummary lists files whose content was adjusted to keep
+// their existing line endings, BOM or trailing newline.
+func FormatNormalizedSummary(report ChangeReport) string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	for _, r := range report.Results {
+		if r.Normalized == "" || seen[r.Path] {
+			continue
+		}
+		seen[r.Path] = true
+		b.WriteString("- " + r.Path + ": " + r.Normalized + "\n")
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\n## Normalized\n" + b.String()
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyChangeSet(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello\nline2\n"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "old.txt"), []byte("bye"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{
+		Writes:  []WriteOp{{Path: "dir/new.txt", Content: "new"}},
+		Deletes: []DeleteOp{{Path: "old.txt"}},
+		Patches: []PatchOp{{Path: "a.txt", Patch: "@@ -1,2 +1,2 @@\n-hello\n+hello world\n line2"}},
+	})
+	if !report.Committed || report.Err != nil {
+		t.Fatalf("expected commit, got %v", report.Err)
+	}
+	if len(report.Writes) != 1 || len(report.Deletes) != 1 || len(report.Patches) != 1 {
+		t.Fatalf("unexpected report: %#v", report)
+	}
+	b, _ := os.ReadFile(filepath.Join(root, "a.txt"))
+	if string(b) != "hello world\nline2\n" {
+		t.Fatalf("unexpected content: %q", string(b))
+	}
+	if _, err := os.Stat(filepath.Join(root, "old.txt")); err == nil {
+		t.Fatal("expected old.txt to be deleted")
+	}
+}
+
+func TestApplyChangeSetValidationFailure(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{
+		Writes:  []WriteOp{{Path: "dir/new.txt", Content: "new"}},
+		Deletes: []DeleteOp{{Path: "missing.txt"}},
+		Patches: []PatchOp{{Path: "a.txt", Patch: "@@ -1,1 +1,1 @@\n-nope\n+yes"}},
+	})
+	if report.Committed || report.Err == nil {
+		t.Fatal("expected change set to fail")
+	}
+	if report.Results[0].Status != OpSkipped {
+		t.Fatalf("expected write skipped, got %q", report.Results[0].Status)
+	}
+	if report.Results[1].Status != OpFailed || report.Results[2].Status != OpFailed {
+		t.Fatalf("unexpected results: %#v", report.Results)
+	}
+	if _, err := os.Stat(filepath.Join(root, "dir")); err == nil {
+		t.Fatal("expected no directories to be created")
+	}
+	if len(report.PatchFailures()) != 1 {
+		t.Fatalf("expected 1 patch failure, got %d", len(report.PatchFailures()))
+	}
+}
+
+func TestRollbackStaged(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("orig"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	a, err := loadStagedFile(root, "a.txt")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	b, _ := loadStagedFile(root, "b.txt")
+	if err := os.WriteFile(a.abs, []byte("changed"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(b.abs, []byte("created"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	a.done = true
+	b.done = true
+	rollbackStaged(map[string]*stagedFile{"a.txt": a, "b.txt": b}, []string{"a.txt", "b.txt"})
+	got, _ := os.ReadFile(a.abs)
+	if string(got) != "orig" {
+		t.Fatalf("expected original content restored, got %q", string(got))
+	}
+	if _, err := os.Stat(b.abs); err == nil {
+		t.Fatal("expected created file to be removed")
+	}
+}
+
+func TestApplyChangeSetStaleRead(t *testing.T) {
+	root := t.TempDir()
+	p := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(p, []byte("hello\n"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	refs := LoadMentionedFiles(root, []string{"a.txt"}, true, 0, 0)
+	if len(refs) != 1 || refs[0].Hash == "" {
+		t.Fatalf("expected hashed ref, got %#v", refs)
+	}
+	if err := os.WriteFile(p, []byte("edited by user\n"), 0644); err != nil {
+		t.Fatalf("write edit: %v", err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{
+		Writes: []WriteOp{{Path: "a.txt", Content: "from model\n"}},
+		Reads:  refs,
+	})
+	if report.Committed {
+		t.Fatal("expected stale write to be refused")
+	}
+	if len(report.Conflicts()) != 1 {
+		t.Fatalf("expected 1 conflict, got %#v", report.Results)
+	}
+	if c := report.Conflicts()[0]; c.Theirs != "from model\n" {
+		t.Fatalf("expected minibrain's version on the conflict, got %q", c.Theirs)
+	}
+	b, _ := os.ReadFile(p)
+	if string(b) != "edited by user\n" {
+		t.Fatalf("expected user edit preserved, got %q", string(b))
+	}
+}
+
+func TestApplyChangeSetCreatesSharedDirs(t *testing.T) {
+	root := t.TempDir()
+	report := ApplyChangeSet(root, ChangeSet{Writes: []WriteOp{{Path: "pkg/sub/a.go", Content: "package sub\n"}}})
+	if !report.Committed {
+		t.Fatalf("apply failed: %v", report.Err)
+	}
+	// Compare with a dir made directly, so the umask does not matter.
+	if err := os.Mkdir(filepath.Join(root, "ref"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.Stat(filepath.Join(root, "ref"))
+	for _, dir := range []string{"pkg", "pkg/sub"} {
+		info, err := os.Stat(filepath.Join(root, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want.Mode().Perm() {
+			t.Fatalf("expected %s to be %v, got %v", dir, want.Mode().Perm(), info.Mode().Perm())
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CheckpointFile struct {
+	Path    string      `json:"path"`
+	Mode    fs.FileMode `json:"mode"`
+	Existed bool        `json:"existed"`
+}
+
+type Checkpoint struct {
+	ID      string           `json:"id"`
+	Created string           `json:"created"`
+	Files   []CheckpointFile `json:"files"`
+}
+
+// maxCheckpoints is how many checkpoints a project keeps; creating one past
+// it drops the oldest.
+const maxCheckpoints = 100
+
+func CheckpointsDir(root string) string {
+	return filepath.Join(root, ".minibrain", "checkpoints")
+}
+
+func CreateCheckpoint(root string, paths []string) (Checkpoint, error) {
+	base := CheckpointsDir(root)
+	if err := ensureDir(base); err != nil {
+		return Checkpoint{}, err
+	}
+	now := time.Now().UTC()
+	stamp := now.Format("20060102-150405")
+	var id, dir string
+	for n := nextCheckpointCounter(base, stamp); ; n++ {
+		id = stamp
+		if n > 0 {
+			id = fmt.Sprintf("%s-%d", stamp, n)
+		}
+		dir = filepath.Join(base, id)
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return Checkpoint{}, err
+		}
+	}
+
+	cp := Checkpoint{ID: id, Created: now.Format(time.RFC3339)}
+	seen := map[string]struct{}{}
+	for _, p := range paths {
+		clean, err := safeRelPath(p)
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return Checkpoint{}, err
+		}
+		if _, ok := seen[clean]; ok {
+			continue
+		}
+		seen[clean] = struct{}{}
+		entry := CheckpointFile{Path: clean, Mode: 0644}
+		info, err := os.Stat(filepath.Join(root, clean))
+		if err == nil {
+			b, err := os.ReadFile(filepath.Join(root, clean))
+			if err != nil {
+				_ = os.RemoveAll(dir)
+				return Checkpoint{}, err
+			}
+			dst := filepath.Join(dir, "files", clean)
+			if err := ensureDir(filepath.Dir(dst)); err != nil {
+				_ = os.RemoveAll(dir)
+				return Checkpoint{}, err
+			}
+			// Snapshots may hold secrets; the mode to restore is in the entry.
+			if err := os.WriteFile(dst, b, 0600); err != nil {
+				_ = os.RemoveAll(dir)
+				return Checkpoint{}, err
+			}
+			entry.Existed = true
+			entry.Mode = info.Mode().Perm()
+		} else if !errors.Is(err, os.ErrNotExist) {
+			_ = os.RemoveAll(dir)
+			return Checkpoint{}, err
+		}
+		cp.Files = append(cp.Files, entry)
+	}
+
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return Checkpoint{}, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "checkpoint.json"), b, 0644); err != nil {
+		_ = os.RemoveAll(dir)
+		return Checkpoint{}, err
+	}
+	// Old checkpoints that cannot be removed now are retried next time.
+	_ = pruneCheckpoints(root)
+	return cp, nil
+}
+
+// pruneCheckpoints removes the oldest checkpoints beyond maxCheckpoints.
+func pruneCheckpoints(root string) error {
+	cps, err := ListCheckpoints(root)
+	if err != nil || len(cps) <= maxCheckpoints {
+		return err
+	}
+	for _, cp := range cps[:len(cps)-maxCheckpoints] {
+		if err := DeleteCheckpoint(root, cp.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ListCheckpoints(root string) ([]Checkpoint, error) {
+	entries, err := os.ReadDir(CheckpointsDir(root))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []Checkpoint
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		cp, err := loadCheckpoint(root, e.Name())
+		if err != nil {
+			continue
+		}
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return checkpointBefore(out[i].ID, out[j].ID) })
+	return out, nil
+}
+
+// checkpointBefore orders IDs by their timestamp, then by the counter added
+// for checkpoints made in the same second.
+func checkpointBefore(a, b string) bool {
+	stampA, nA := splitCheckpointID(a)
+	stampB, nB := splitCheckpointID(b)
+	if stampA != stampB {
+		return stampA < stampB
+	}
+	return nA < nB
+}
+
+// nextCheckpointCounter returns the counter for a new checkpoint made at
+// stamp: 0 for none, or one past the highest already used, so IDs keep
+// increasing even after older ones from the same second are pruned.
+func nextCheckpointCounter(base, stamp string) int {
+	entries, _ := os.ReadDir(base)
+	next := 0
+	for _, e := range entries {
+		if s, n := splitCheckpointID(e.Name()); s == stamp {
+			next = max(next, n+1)
+		}
+	}
+	return next
+}
+
+func splitCheckpointID(id string) (string, int) {
+	if i := strings.LastIndexByte(id, '-'); i > 0 && strings.Count(id, "-") == 2 {
+		if n, err := strconv.Atoi(id[i+1:]); err == nil {
+			return id[:i], n
+		}
+	}
+	return id, 0
+}
+
+// RestoreCheckpoint puts back the files in checkpoint id. The current state
+// of those files is checkpointed first, so the restore can be undone.
+func RestoreCheckpoint(root, id string) (Checkpoint, error) {
+	return restoreCheckpoint(root, id, true)
+}
+
+// restoreCheckpoint checks every file against the path rules before
+// touching any, and reads the snapshots before a new checkpoint can prune
+// the one being restored.
+func restoreCheckpoint(root, id string, save bool) (Checkpoint, error) {
+	cp, err := loadCheckpoint(root, id)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	rules, err := LoadPathRules(root)
+	if err != nil {
+		return cp, err
+	}
+	dir := filepath.Join(CheckpointsDir(root), cp.ID)
+	contents := make([][]byte, len(cp.Files))
+	paths := make([]string, len(cp.Files))
+	for i, f := range cp.Files {
+		clean, err := safeRelPath(f.Path)
+		if err != nil {
+			return cp, err
+		}
+		kind := "WRITE"
+		if !f.Existed {
+			kind = "DELETE"
+		}
+		if err := rules.checkOp(kind, clean); err != nil {
+			return cp, err
+		}
+		if f.Existed {
+			if contents[i], err = os.ReadFile(filepath.Join(dir, "files", clean)); err != nil {
+				return cp, err
+			}
+		}
+		paths[i] = clean
+	}
+	if save {
+		if _, err := CreateCheckpoint(root, paths); err != nil {
+			return cp, fmt.Errorf("failed to checkpoint current files: %w", err)
+		}
+	}
+	for i, f := range cp.Files {
+		abs := filepath.Join(root, paths[i])
+		if !f.Existed {
+			if err := os.Remove(abs); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return cp, err
+			}
+			continue
+		}
+		if err := ensureDir(filepath.Dir(abs)); err != nil {
+			return cp, err
+		}
+		tmp, err := writeTemp(abs, contents[i], f.Mode)
+		if err != nil {
+			return cp, err
+		}
+		if err := os.Rename(tmp, abs); err != nil {
+			_ = os.Remove(tmp)
+			return cp, err
+		}
+	}
+	return cp, nil
+}
+
+func UndoLastCheckpoint(root string) (Checkpoint, error) {
+	cps, err := ListCheckpoints(root)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if len(cps) == 0 {
+		return Checkpoint{}, errors.New("no checkpoints to undo")
+	}
+	last := cps[len(cps)-1]
+	cp, err := restoreCheckpoint(root, last.ID, false)
+	if err != nil {
+		return cp, err
+	}
+	return cp, DeleteCheckpoint(root, cp.ID)
+}
+
+func DeleteCheckpoint(root, id string) error {
+	if err := validCheckpointID(id); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(CheckpointsDir(root), id))
+}
+
+func FormatCheckpoint(cp Checkpoint) string {
+	var paths []string
+	for _, f := range cp.Files {
+		if f.Existed {
+			paths = append(paths, f.Path)
+		} else {
+			paths = append(paths, f.Path+" (new)")
+		}
+	}
+	return cp.ID + " " + cp.Created + " " + strings.Join(paths, ", ")
+}
+
+func loadCheckpoint(root, id string) (Checkpoint, error) {
+	if err := validCheckpointID(id); err != nil {
+		return Checkpoint{}, err
+	}
+	b, err := os.ReadFile(filepath.Join(CheckpointsDir(root), id, "checkpoint.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Checkpoint{}, errors.New("checkpoint not found: " + id)
+		}
+		return Checkpoint{}, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return Checkpoint{}, err
+	}
+	cp.ID = id
+	return cp, nil
+}
+
+func validCheckpointID(id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return errors.New("invalid checkpoint id")
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUndoRestoresChangeSet(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0755); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "b.txt"), []byte("b"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{
+		Writes:  []WriteOp{{Path: "a.txt", Content: "changed"}, {Path: "new.txt", Content: "new"}},
+		Deletes: []DeleteOp{{Path: "b.txt"}},
+	})
+	if !report.Committed || report.Checkpoint == "" {
+		t.Fatalf("expected committed change set with checkpoint, got %#v", report)
+	}
+	cps, err := ListCheckpoints(root)
+	if err != nil || len(cps) != 1 {
+		t.Fatalf("expected 1 checkpoint, got %d (%v)", len(cps), err)
+	}
+
+	if _, err := UndoLastCheckpoint(root); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(root, "a.txt"))
+	if err != nil || string(b) != "a" {
+		t.Fatalf("expected a.txt restored, got %q (%v)", string(b), err)
+	}
+	info, _ := os.Stat(filepath.Join(root, "a.txt"))
+	if info.Mode().Perm() != 0755 {
+		t.Fatalf("expected mode 0755, got %v", info.Mode().Perm())
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "b.txt")); err != nil || string(b) != "b" {
+		t.Fatalf("expected b.txt recreated, got %q (%v)", string(b), err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new.txt")); err == nil {
+		t.Fatal("expected new.txt to be removed")
+	}
+	if cps, _ := ListCheckpoints(root); len(cps) != 0 {
+		t.Fatalf("expected checkpoint to be popped, got %d", len(cps))
+	}
+}
+
+func TestRestoreCheckpointInvalidID(t *testing.T) {
+	if _, err := RestoreCheckpoint(t.TempDir(), "../x"); err == nil {
+		t.Fatal("expected error for invalid id")
+	}
+}
+
+func TestCheckpointOrderAndRetention(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var last Checkpoint
+	for i := 0; i < maxCheckpoints+5; i++ {
+		cp, err := CreateCheckpoint(root, []string{"a.txt"})
+		if err != nil {
+			t.Fatalf("checkpoint %d: %v", i, err)
+		}
+		last = cp
+	}
+	cps, err := ListCheckpoints(root)
+	if err != nil || len(cps) != maxCheckpoints {
+		t.Fatalf("expected %d checkpoints, got %d (%v)", maxCheckpoints, len(cps), err)
+	}
+	if cps[len(cps)-1].ID != last.ID {
+		t.Fatalf("expected %s last, got %s", last.ID, cps[len(cps)-1].ID)
+	}
+	if !checkpointBefore("20250101-120000-2", "20250101-120000-10") || !checkpointBefore("20250101-120000", "20250101-120000-1") {
+		t.Fatal("expected checkpoints in the same second to sort by counter")
+	}
+}
+
+func TestRestoreCheckpointIsUndoableAndFollowsRules(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := CreateCheckpoint(root, []string{"a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(CheckpointsDir(root), cp.ID, "files", "a.txt")); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected a private snapshot, got %v (%v)", info, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("two"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreCheckpoint(root, cp.ID); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(b) != "one" {
+		t.Fatalf("expected a.txt restored, got %q", b)
+	}
+	if _, err := UndoLastCheckpoint(root); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(b) != "two" {
+		t.Fatalf("expected the restore undone, got %q", b)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, ".minibrain"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ProjectConfigPath(root), []byte(`{"permissions": {"write": {"deny": ["a.txt"]}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreCheckpoint(root, cp.ID); err == nil {
+		t.Fatal("expected the write rule to block the restore")
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(b) != "two" {
+		t.Fatalf("a.txt should be untouched, got %q", b)
+	}
+}
@@ -0,0 +1,395 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/llm"
+)
+
+// Short-term memory is condensed in layers: old turns become turn summaries,
+// turn summaries fold into the session summary, and the session summary
+// folds into the epoch summary. Every threshold is in estimated tokens.
+const (
+	defaultStmMaxTokens = 3000
+	defaultStmKeepTurns = 3
+	turnLayerTokens     = 1200
+	sessionLayerTokens  = 600
+)
+
+const (
+	prefrontalTitle = "# Session Memory (PREFRONTAL)\n\n"
+	turnMarker      = prefrontalTitle + "- Started: "
+)
+
+var errNothingToCondense = errors.New("nothing to condense yet; the most recent turns are kept verbatim")
+
+// condenseCall is the model call used for summaries; tests replace it.
+var condenseCall = llm.CallOpenAIJSON
+
+// Digest is a structured summary of one turn or of a whole layer.
+type Digest struct {
+	Summary   string   `json:"summary"`
+	Files     []string `json:"files"`
+	Decisions []string `json:"decisions"`
+	TODOs     []string `json:"todos"`
+}
+
+// stmLayers is PREFRONTAL.md split into its summary layers and the turns
+// still kept verbatim, oldest first.
+type stmLayers struct {
+	Epoch   string
+	Session string
+	Turns   []string
+	Raw     []string
+}
+
+var digestSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "summary": {"type": "string"},
+    "files": {"type": "array", "items": {"type": "string"}},
+    "decisions": {"type": "array", "items": {"type": "string"}},
+    "todos": {"type": "array", "items": {"type": "string"}}
+  },
+  "required": ["summary", "files", "decisions", "todos"],
+  "additionalProperties": false
+}`)
+
+var turnsSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {"turns": {"type": "array", "items": ` + string(digestSchema) + `}},
+  "required": ["turns"],
+  "additionalProperties": false
+}`)
+
+const digestRules = "Keep file paths exactly as written. decisions are choices that were made; todos are follow-ups still open. " +
+	"Leave a list empty rather than guessing. summary is one to three plain sentences."
+
+func parseShortTerm(content string) stmLayers {
+	var l stmLayers
+	var starts []int
+	for i := 0; ; {
+		j := strings.Index(content[i:], turnMarker)
+		if j < 0 {
+			break
+		}
+		starts = append(starts, i+j)
+		i += j + len(turnMarker)
+	}
+	preamble := content
+	if len(starts) > 0 {
+		preamble = content[:starts[0]]
+	}
+	for i, start := range starts {
+		end := len(content)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		l.Raw = append(l.Raw, content[start:end])
+	}
+
+	var section string
+	var legacy, cur strings.Builder
+	flush := func() {
+		text := strings.TrimSpace(cur.String())
+		cur.Reset()
+		switch section {
+		case "epoch":
+			l.Epoch = text
+		case "session":
+			l.Session = text
+		}
+	}
+	for _, line := range strings.SplitAfter(preamble, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "## Epoch Summary" || trimmed == "## Session Summary" || trimmed == "## Turn Summaries":
+			flush()
+			section = strings.ToLower(strings.Fields(trimmed)[1])
+			continue
+		case section == "turn" && strings.HasPrefix(trimmed, "### "):
+			l.Turns = append(l.Turns, "")
+		}
+		switch section {
+		case "":
+			// Title, metadata bullets, and summaries written before layers
+			// existed, which are kept as the session summary.
+			if strings.HasPrefix(trimmed, "# ") || trimmed == "---" || isMetaLine(trimmed) {
+				continue
+			}
+			legacy.WriteString(line)
+		case "turn":
+			if len(l.Turns) > 0 && trimmed != "---" {
+				l.Turns[len(l.Turns)-1] += line
+			}
+		default:
+			if trimmed != "---" {
+				cur.WriteString(line)
+			}
+		}
+	}
+	flush()
+	for i := range l.Turns {
+		l.Turns[i] = strings.TrimSpace(l.Turns[i])
+	}
+	if text := strings.TrimSpace(legacy.String()); text != "" {
+		l.Session = strings.TrimSpace(text + "\n\n" + l.Session)
+	}
+	return l
+}
+
+func isMetaLine(line string) bool {
+	for _, p := range []string{"- Condensed:", "- Cleared:", "- Initialized:", "- Notes:"} {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// summaryText renders the summary layers without the verbatim turns.
+func (l stmLayers) summaryText() string {
+	var b strings.Builder
+	if l.Epoch != "" {
+		b.WriteString("## Epoch Summary\n" + l.Epoch + "\n\n")
+	}
+	if l.Session != "" {
+		b.WriteString("## Session Summary\n" + l.Session + "\n\n")
+	}
+	if len(l.Turns) > 0 {
+		b.WriteString("## Turn Summaries\n" + strings.Join(l.Turns, "\n\n") + "\n\n")
+	}
+	return b.String()
+}
+
+func (l stmLayers) render() string {
+	var b strings.Builder
+	b.WriteString(prefrontalTitle)
+	b.WriteString("- Condensed: " + time.Now().Format(time.RFC3339) + "\n\n")
+	b.WriteString(l.summaryText())
+	if len(l.Raw) > 0 {
+		b.WriteString("---\n\n")
+		b.WriteString(strings.Join(l.Raw, ""))
+	}
+	return b.String()
+}
+
+// keepTurns is how many recent turns stay verbatim: StmKeepTurns, fewer if
+// they alone would take more than half the short-term budget, but at least
+// one.
+func keepTurns(cfg Config, raw []string) int {
+	keep := cfg.StmKeepTurns
+	if keep <= 0 {
+		keep = defaultStmKeepTurns
+	}
+	if keep > len(raw) {
+		keep = len(raw)
+	}
+	for keep > 1 && EstimateTokens(strings.Join(raw[len(raw)-keep:], "")) > stmMaxTokens(cfg)/2 {
+		keep--
+	}
+	return keep
+}
+
+func stmMaxTokens(cfg Config) int {
+	if cfg.StmMaxTokens > 0 {
+		return cfg.StmMaxTokens
+	}
+	return defaultStmMaxTokens
+}
+
+func (l stmLayers) layersOverBudget() bool {
+	return EstimateTokens(strings.Join(l.Turns, "\n\n")) > turnLayerTokens || EstimateTokens(l.Session) > sessionLayerTokens
+}
+
+// condenseLayers summarizes all but the last keep turns and folds layers
+// that have outgrown their budget into the next one up.
+func condenseLayers(cfg Config, l stmLayers, keep int) (stmLayers, error) {
+	if older := l.Raw[:len(l.Raw)-keep]; len(older) > 0 {
+		digests, err := summarizeTurns(cfg, older)
+		if err != nil {
+			return l, err
+		}
+		for i, d := range digests {
+			l.Turns = append(l.Turns, turnHeading(older[i])+"\n"+renderDigest(d))
+		}
+		l.Raw = l.Raw[len(older):]
+	}
+	if len(l.Turns) > 1 && EstimateTokens(strings.Join(l.Turns, "\n\n")) > turnLayerTokens {
+		n := 1
+		for n < len(l.Turns)-1 && EstimateTokens(strings.Join(l.Turns[n:], "\n\n")) > turnLayerTokens/2 {
+			n++
+		}
+		d, err := mergeDigests(cfg, "session", l.Session, l.Turns[:n])
+		if err != nil {
+			return l, err
+		}
+		l.Session, l.Turns = renderDigest(d), l.Turns[n:]
+	}
+	if EstimateTokens(l.Session) > sessionLayerTokens {
+		d, err := mergeDigests(cfg, "epoch", l.Epoch, []string{l.Session})
+		if err != nil {
+			return l, err
+		}
+		l.Epoch, l.Session = renderDigest(d), ""
+	}
+	return l, nil
+}
+
+func summarizeTurns(cfg Config, turns []string) ([]Digest, error) {
+	var in strings.Builder
+	for i, t := range turns {
+		fmt.Fprintf(&in, "Turn %d:\n%s\n\n", i+1, strings.TrimSpace(t))
+	}
+	dev := "You condense an agent's short-term memory. Summarize each turn separately and in order, returning exactly one entry per turn. " + digestRules
+	var out struct {
+		Turns []Digest `json:"turns"`
+	}
+	if err := condenseJSON(cfg, dev, in.String(), "minibrain_turns", turnsSchema, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Turns) != len(turns) {
+		return nil, fmt.Errorf("model summarized %d of %d turns", len(out.Turns), len(turns))
+	}
+	return out.Turns, nil
+}
+
+func mergeDigests(cfg Config, layer, existing string, parts []string) (Digest, error) {
+	var in strings.Builder
+	if existing != "" {
+		in.WriteString("Current " + layer + " summary:\n" + existing + "\n\n")
+	}
+	in.WriteString("Newer summaries to fold in:\n" + strings.Join(parts, "\n\n") + "\n")
+	dev := fmt.Sprintf("You merge summaries of an agent's work into one %s summary of at most %d tokens. "+
+		"Keep every file path and decision that still matters, and every TODO not completed later. ", layer, sessionLayerTokens) + digestRules
+	var d Digest
+	err := condenseJSON(cfg, dev, in.String(), "minibrain_digest", digestSchema, &d)
+	return d, err
+}
+
+func condenseJSON(cfg Config, dev, input, name string, schema json.RawMessage, v any) error {
+	model := cfg.Model
+	if model == "" {
+		model = "gpt-4.1"
+	}
+	ctx, cancel := contextWithTimeout(cfg.TimeoutSec)
+	defer cancel()
+	out, err := condenseCall(ctx, model, dev, redactText(cfg, cfg.BrainDir, "condense", input), name, schema)
+	if err != nil {
+		return err
+	}
+	out = redactText(cfg, cfg.BrainDir, "condense", out)
+	if err := json.Unmarshal([]byte(out), v); err != nil {
+		return errors.New("model returned invalid summary JSON")
+	}
+	return nil
+}
+
+func turnHeading(turn string) string {
+	var started, prompt string
+	for _, line := range strings.Split(turn, "\n") {
+		if v, ok := strings.CutPrefix(line, "- Started: "); ok && started == "" {
+			started = strings.TrimSpace(v)
+		}
+		if v, ok := strings.CutPrefix(line, "- Prompt: "); ok && prompt == "" {
+			prompt = strings.TrimSpace(v)
+		}
+	}
+	if EstimateTokens(prompt) > 20 {
+		prompt = headTokens(prompt, 20) + "…"
+	}
+	return strings.TrimSpace("### " + started + " " + prompt)
+}
+
+func renderDigest(d Digest) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(d.Summary) + "\n")
+	for _, list := range []struct {
+		title string
+		items []string
+	}{{"Files", d.Files}, {"Decisions", d.Decisions}, {"TODOs", d.TODOs}} {
+		var items []string
+		for _, it := range list.items {
+			if it = strings.TrimSpace(it); it != "" {
+				items = append(items, it)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		b.WriteString(list.title + ":\n")
+		for _, it := range items {
+			b.WriteString("- " + it + "\n")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// CondenseShortTerm summarizes all but the most recent turns of short-term
+// memory and returns the resulting summary layers.
+func CondenseShortTerm(cfg Config) (string, error) {
+	prefrontalPath := resolvePrefrontalPath(cfg)
+	content, err := readFileOrEmpty(prefrontalPath)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", nil
+	}
+	layers := parseShortTerm(content)
+	keep := keepTurns(cfg, layers.Raw)
+	if len(layers.Raw) <= keep && !layers.layersOverBudget() {
+		return "", errNothingToCondense
+	}
+	older := strings.Join(layers.Raw[:len(layers.Raw)-keep], "")
+	layers, err = condenseLayers(cfg, layers, keep)
+	if err != nil {
+		return "", err
+	}
+
+	// The model calls run unlocked, so keep anything appended meanwhile and
+	// give up if the notes were rewritten under us.
+	err = withFileLock(prefrontalPath, func() error {
+		current, err := readFileOrEmpty(prefrontalPath)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(current, content) {
+			return errors.New("PREFRONTAL.md changed during condense; try again")
+		}
+		return writeBrainFileLocked(prefrontalPath, layers.render()+current[len(content):], "condense")
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Promote from the summarized turns rather than their summaries, which
+	// drop detail. A failed promotion pass does not fail the condense.
+	_, _, _ = promoteFrom(cfg, older)
+
+	return layers.summaryText(), nil
+}
+
+// AutoCondenseIfNeeded condenses when short-term memory is over
+// StmMaxTokens and there are turns old enough to summarize.
+func AutoCondenseIfNeeded(cfg Config) (bool, error) {
+	content, err := readFileOrEmpty(resolvePrefrontalPath(cfg))
+	if err != nil {
+		return false, err
+	}
+	if EstimateTokens(content) <= stmMaxTokens(cfg) {
+		return false, nil
+	}
+	layers := parseShortTerm(content)
+	if len(layers.Raw) <= keepTurns(cfg, layers.Raw) && !layers.layersOverBudget() {
+		return false, nil
+	}
+	if _, err := CondenseShortTerm(cfg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens("hello world"); got != 4 {
+		t.Fatalf("expected 4, got %d", got)
+	}
+	if got := EstimateTokens("a.b, c"); got != 5 {
+		t.Fatalf("expected 5, got %d", got)
+	}
+	text := "one\ntwo\nthree\n"
+	if got := tailTokens(text, 3); got != "two\nthree\n" {
+		t.Fatalf("unexpected tail %q", got)
+	}
+	if got := headTokens("alpha beta gamma", 4); got != "alpha beta" {
+		t.Fatalf("unexpected head %q", got)
+	}
+}
+
+// stubCondense answers turn requests with one digest per turn and merge
+// requests with a fixed digest, counting the calls made.
+func stubCondense(t *testing.T) *int {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("MINIBRAIN_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	calls := 0
+	prev := condenseCall
+	condenseCall = func(_ context.Context, _, _, user, name string, _ json.RawMessage) (string, error) {
+		calls++
+		if name == "minibrain_turns" {
+			var turns []Digest
+			for i := 1; strings.Contains(user, fmt.Sprintf("Turn %d:", i)); i++ {
+				turns = append(turns, Digest{
+					Summary:   fmt.Sprintf("Summarized turn %d.", i),
+					Files:     []string{"internal/agent/condense.go"},
+					Decisions: []string{"keep recent turns verbatim"},
+					TODOs:     []string{"write docs"},
+				})
+			}
+			b, _ := json.Marshal(map[string]any{"turns": turns})
+			return string(b), nil
+		}
+		b, _ := json.Marshal(Digest{Summary: "Merged summary.", Files: []string{"README.md"}})
+		return string(b), nil
+	}
+	t.Cleanup(func() { condenseCall = prev })
+	return &calls
+}
+
+func writeTurns(t *testing.T, path string, n int, filler string) {
+	t.Helper()
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "%s- Started: 2026-01-0%dT10:00:00Z\n- Prompt: task %d\n\n## Response\n%s\n\n---\n\n", prefrontalTitle, i, i, filler)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCondenseKeepsRecentTurns(t *testing.T) {
+	stubCondense(t)
+	brainDir := t.TempDir()
+	cfg := Config{BrainDir: brainDir, StmKeepTurns: 2}
+	path := resolvePrefrontalPath(cfg)
+	writeTurns(t, path, 5, "did the work")
+
+	summary, err := CondenseShortTerm(cfg)
+	if err != nil {
+		t.Fatalf("condense: %v", err)
+	}
+	if !strings.Contains(summary, "Summarized turn 3.") || !strings.Contains(summary, "Files:\n- internal/agent/condense.go") ||
+		!strings.Contains(summary, "Decisions:\n- keep recent turns verbatim") || !strings.Contains(summary, "TODOs:\n- write docs") {
+		t.Fatalf("unexpected summary:\n%s", summary)
+	}
+	b, _ := os.ReadFile(path)
+	l := parseShortTerm(string(b))
+	if len(l.Turns) != 3 || len(l.Raw) != 2 {
+		t.Fatalf("expected 3 summarized and 2 verbatim turns, got %d and %d", len(l.Turns), len(l.Raw))
+	}
+	if !strings.Contains(l.Raw[0], "- Prompt: task 4") || !strings.Contains(l.Raw[1], "- Prompt: task 5") {
+		t.Fatalf("expected the last turns verbatim, got %q", l.Raw)
+	}
+	if !strings.HasPrefix(l.Turns[0], "### 2026-01-01T10:00:00Z task 1") {
+		t.Fatalf("unexpected turn heading %q", l.Turns[0])
+	}
+
+	// Parsing the rendered file gives back the same layers.
+	again := parseShortTerm(l.render())
+	if strings.Join(again.Turns, "|") != strings.Join(l.Turns, "|") || strings.Join(again.Raw, "") != strings.Join(l.Raw, "") {
+		t.Fatal("render and parse did not round-trip")
+	}
+
+	if _, err := CondenseShortTerm(cfg); err != errNothingToCondense {
+		t.Fatalf("expected nothing to condense, got %v", err)
+	}
+}
+
+func TestCondenseFoldsLayers(t *testing.T) {
+	stubCondense(t)
+	cfg := Config{BrainDir: t.TempDir(), StmKeepTurns: 1}
+	l := stmLayers{Session: strings.Repeat("older session notes ", 200)}
+	for i := 0; i < 4; i++ {
+		l.Turns = append(l.Turns, "### turn\n"+strings.Repeat("detail ", 500))
+	}
+	l.Raw = []string{prefrontalTitle + "- Started: now\n- Prompt: latest\n"}
+
+	out, err := condenseLayers(cfg, l, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Turns) >= len(l.Turns) || len(out.Raw) != 1 || !strings.HasPrefix(out.Session, "Merged summary.") {
+		t.Fatalf("expected turn summaries to fold into the session, got %d turns, session %q", len(out.Turns), out.Session)
+	}
+
+	l = stmLayers{Session: strings.Repeat("older session notes ", 200), Raw: l.Raw}
+	out, err = condenseLayers(cfg, l, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Session != "" || !strings.Contains(out.Epoch, "Merged summary.") || !strings.Contains(out.Epoch, "- README.md") {
+		t.Fatalf("expected the session to fold into the epoch, got %+v", out)
+	}
+}
+
+func TestAutoCondenseUsesTokens(t *testing.T) {
+	calls := stubCondense(t)
+	cfg := Config{BrainDir: t.TempDir(), StmMaxTokens: 500, StmKeepTurns: 1}
+	path := resolvePrefrontalPath(cfg)
+
+	writeTurns(t, path, 3, "short")
+	if did, err := AutoCondenseIfNeeded(cfg); err != nil || did {
+		t.Fatalf("expected no condense under the threshold, got %v %v", did, err)
+	}
+	writeTurns(t, path, 3, strings.Repeat("longer response text ", 60))
+	if did, err := AutoCondenseIfNeeded(cfg); err != nil || !did {
+		t.Fatalf("expected condense over the threshold, got %v %v", did, err)
+	}
+	if *calls == 0 {
+		t.Fatal("expected a summary call")
+	}
+	b, _ := os.ReadFile(path)
+	if l := parseShortTerm(string(b)); len(l.Raw) != 1 || !strings.Contains(l.Raw[0], "task 3") {
+		t.Fatalf("expected only the last turn verbatim, got %q", l.Raw)
+	}
+}
+
+func TestParseLegacySummary(t *testing.T) {
+	l := parseShortTerm(prefrontalTitle + "- Condensed: 2026-01-01T00:00:00Z\n\nOld one-block summary.\n")
+	if l.Session != "Old one-block summary." || len(l.Raw) != 0 {
+		t.Fatalf("expected legacy text as the session summary, got %+v", l)
+	}
+}
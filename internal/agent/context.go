@@ -1,97 +1,62 @@
 package agent
 
 import (
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
-func buildShortTermContext(prefrontalPath string, maxBytes int) string {
-	if maxBytes <= 0 {
-		return ""
-	}
-	content, err := readFileOrEmpty(prefrontalPath)
-	if err != nil {
-		return ""
-	}
-	trim := strings.TrimSpace(content)
-	if trim == "" {
-		return ""
-	}
-	if len(content) <= maxBytes {
-		return content
-	}
-	start := len(content) - maxBytes
-	if start < 0 {
-		start = 0
-	}
-	chunk := content[start:]
-	if idx := strings.Index(chunk, "\n"); idx > 0 && idx < len(chunk)-1 {
-		chunk = chunk[idx+1:]
-	}
-	return strings.TrimSpace(chunk)
+func buildShortTermContext(prefrontalPath string, maxTokens int) string {
+	return loadTail(prefrontalPath, maxTokens)
 }
 
-func loadConversationContext(brainDir string, maxBytes int) string {
-	if maxBytes <= 0 {
+func loadConversationContext(path string, maxTokens int) string {
+	return loadTail(path, maxTokens)
+}
+
+// loadTail returns as much of the end of path as fits in maxTokens.
+func loadTail(path string, maxTokens int) string {
+	if maxTokens <= 0 {
 		return ""
 	}
-	path := filepath.Join(brainDir, "cortex", "CONTEXT.md")
 	content, err := readFileOrEmpty(path)
-	if err != nil {
+	if err != nil || strings.TrimSpace(content) == "" {
 		return ""
 	}
-	trim := strings.TrimSpace(content)
-	if trim == "" {
-		return ""
-	}
-	if len(content) <= maxBytes {
+	if EstimateTokens(content) <= maxTokens {
 		return content
 	}
-	start := len(content) - maxBytes
-	if start < 0 {
-		start = 0
-	}
-	chunk := content[start:]
-	if idx := strings.Index(chunk, "\n"); idx > 0 && idx < len(chunk)-1 {
-		chunk = chunk[idx+1:]
-	}
-	return strings.TrimSpace(chunk)
+	return strings.TrimSpace(tailTokens(content, maxTokens))
 }
 
-func appendConversationContext(brainDir, prompt, response string, maxBytes int) {
-	if maxBytes <= 0 {
+const maxResponseSummaryTokens = 200
+
+func appendConversationContext(path, prompt, response string, maxTokens int) {
+	if maxTokens <= 0 {
 		return
 	}
-	path := filepath.Join(brainDir, "cortex", "CONTEXT.md")
-	_ = ensureDir(filepath.Dir(path))
+	_ = ensurePrivateDir(filepath.Dir(path))
 
 	summary := strings.TrimSpace(response)
-	if len(summary) > 800 {
-		summary = summary[:800] + "…"
+	if EstimateTokens(summary) > maxResponseSummaryTokens {
+		summary = headTokens(summary, maxResponseSummaryTokens) + "…"
 	}
 	entry := "## " + time.Now().UTC().Format(time.RFC3339) + "\n" +
 		"Prompt: " + strings.TrimSpace(prompt) + "\n\n" +
 		"Response: " + summary + "\n\n"
 
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return
-	}
-	_, _ = f.WriteString(entry)
-	_ = f.Close()
-
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return
-	}
-	if len(b) <= maxBytes {
-		return
-	}
-	trimmed := string(b[len(b)-maxBytes:])
-	if idx := strings.Index(trimmed, "\n## "); idx > 0 && idx < len(trimmed)-1 {
-		trimmed = trimmed[idx+1:]
-	}
-	_ = os.WriteFile(path, []byte(strings.TrimSpace(trimmed)+"\n"), 0644)
+	_ = withFileLock(path, func() error {
+		if err := appendFile(path, []byte(entry)); err != nil {
+			return err
+		}
+		b, err := readFileOrEmpty(path)
+		if err != nil || EstimateTokens(b) <= maxTokens {
+			return err
+		}
+		trimmed := tailTokens(b, maxTokens)
+		if idx := strings.Index(trimmed, "\n## "); !strings.HasPrefix(trimmed, "## ") && idx > 0 && idx < len(trimmed)-1 {
+			trimmed = trimmed[idx+1:]
+		}
+		return writeBrainFileLocked(path, strings.TrimSpace(trimmed)+"\n", "trim")
+	})
 }
@@ -0,0 +1,276 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// maxDiffEdits bounds the Myers search; beyond it the remaining middle
+// section is reported as a full replacement.
+const maxDiffEdits = 2000
+
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	out := make([]diffLine, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		out = append(out, diffLine{op: diffEqual, text: l})
+	}
+	out = append(out, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, l := range a[len(a)-suffix:] {
+		out = append(out, diffLine{op: diffEqual, text: l})
+	}
+	return out
+}
+
+func myersDiff(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		if d > maxDiffEdits {
+			return replaceAll(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(trace, a, b)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+func backtrackDiff(trace [][]int, a, b []string) []diffLine {
+	var out []diffLine
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			out = append(out, diffLine{op: diffEqual, text: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				out = append(out, diffLine{op: diffInsert, text: b[y-1]})
+			} else {
+				out = append(out, diffLine{op: diffDelete, text: a[x-1]})
+			}
+			x, y = prevX, prevY
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+func replaceAll(a, b []string) []diffLine {
+	out := make([]diffLine, 0, len(a)+len(b))
+	for _, l := range a {
+		out = append(out, diffLine{op: diffDelete, text: l})
+	}
+	for _, l := range b {
+		out = append(out, diffLine{op: diffInsert, text: l})
+	}
+	return out
+}
+
+// matchLines maps each line of a to its matching line in b, or -1.
+func matchLines(a, b []string) []int {
+	match := make([]int, len(a))
+	i, j := 0, 0
+	for _, d := range diffLines(a, b) {
+		switch d.op {
+		case diffEqual:
+			match[i] = j
+			i++
+			j++
+		case diffDelete:
+			match[i] = -1
+			i++
+		case diffInsert:
+			j++
+		}
+	}
+	return match
+}
+
+// buildHunks groups a line diff into unified-diff hunks with the given
+// number of context lines around each change.
+func buildHunks(lines []diffLine, context int) []hunk {
+	var hunks []hunk
+	oldLine, newLine := 1, 1
+	oldPos := make([]int, len(lines))
+	newPos := make([]int, len(lines))
+	var changes []int
+	for i, l := range lines {
+		oldPos[i], newPos[i] = oldLine, newLine
+		switch l.op {
+		case diffEqual:
+			oldLine++
+			newLine++
+		case diffDelete:
+			oldLine++
+			changes = append(changes, i)
+		case diffInsert:
+			newLine++
+			changes = append(changes, i)
+		}
+	}
+	for c := 0; c < len(changes); {
+		start := changes[c] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[c]
+		for c < len(changes) && changes[c] <= end+2*context+1 {
+			end = changes[c]
+			c++
+		}
+		end += context
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		h := hunk{oldStart: oldPos[start], newStart: newPos[start]}
+		for _, l := range lines[start : end+1] {
+			switch l.op {
+			case diffEqual:
+				h.lines = append(h.lines, " "+l.text)
+				h.oldCount++
+				h.newCount++
+			case diffDelete:
+				h.lines = append(h.lines, "-"+l.text)
+				h.oldCount++
+			case diffInsert:
+				h.lines = append(h.lines, "+"+l.text)
+				h.newCount++
+			}
+		}
+		if h.oldCount == 0 {
+			h.oldStart--
+		}
+		if h.newCount == 0 {
+			h.newStart--
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+func formatHunkHeader(h hunk) string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.oldStart, h.oldCount, h.newStart, h.newCount)
+}
+
+func UnifiedDiff(path, oldContent, newContent string, context int) string {
+	hunks := buildHunks(diffLines(splitLines(oldContent), splitLines(newContent)), context)
+	if len(hunks) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("--- a/" + path + "\n")
+	b.WriteString("+++ b/" + path + "\n")
+	for _, h := range hunks {
+		b.WriteString(formatHunkHeader(h) + "\n")
+		for _, l := range h.lines {
+			b.WriteString(l + "\n")
+		}
+	}
+	return b.String()
+}
+
+func DiffStats(oldContent, newContent string) (added, removed int) {
+	for _, l := range diffLines(splitLines(oldContent), splitLines(newContent)) {
+		switch l.op {
+		case diffInsert:
+			added++
+		case diffDelete:
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func PatchStats(patch string) (added, removed int) {
+	for _, h := range parseHunks(patch) {
+		for _, l := range h.lines {
+			switch {
+			case strings.HasPrefix(l, "+"):
+				added++
+			case strings.HasPrefix(l, "-"):
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
+
+func AnnotateWriteStats(root string, writes []WriteOp) []WriteOp {
+	out := make([]WriteOp, len(writes))
+	for i, w := range writes {
+		old := ""
+		if clean, err := safeRelPath(w.Path); err == nil {
+			old, _ = readTextOrEmpty(filepath.Join(root, clean))
+		}
+		w.Added, w.Removed = DiffStats(old, w.Content)
+		out[i] = w
+	}
+	return out
+}
+
+func WriteDiff(root string, w WriteOp) string {
+	old := ""
+	if clean, err := safeRelPath(w.Path); err == nil {
+		old, _ = readTextOrEmpty(filepath.Join(root, clean))
+	}
+	return UnifiedDiff(w.Path, old, w.Content, 3)
+}
@@ -0,0 +1,43 @@
+package agent
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	updated := "a\nb\nC\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	got := UnifiedDiff("x.txt", old, updated, 1)
+	want := "--- a/x.txt\n+++ b/x.txt\n" +
+		"@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n" +
+		"@@ -10,1 +10,2 @@\n j\n+k\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if applied, ok := applyUnifiedPatch(old, got); !ok || applied != updated {
+		t.Fatalf("diff does not round-trip: %q", applied)
+	}
+}
+
+func TestDiffStats(t *testing.T) {
+	added, removed := DiffStats("a\nb\nc\n", "a\nx\ny\nc\n")
+	if added != 2 || removed != 1 {
+		t.Fatalf("expected +2 -1, got +%d -%d", added, removed)
+	}
+	added, removed = DiffStats("", "new\n")
+	if added != 1 || removed != 0 {
+		t.Fatalf("expected +1 -0, got +%d -%d", added, removed)
+	}
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	a := []string{"a", "b", "c", "a", "b", "b", "a"}
+	b := []string{"c", "b", "a", "b", "a", "c"}
+	edits := 0
+	for _, d := range diffLines(a, b) {
+		if d.op != diffEqual {
+			edits++
+		}
+	}
+	if edits != 5 {
+		t.Fatalf("expected 5 edits, got %d", edits)
+	}
+}
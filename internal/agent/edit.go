@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ApplyEdit replaces OldString with NewString in content. An exact match is
+// tried first; if there is none, lines are compared with surrounding
+// whitespace ignored and the replacement is re-indented to match the file.
+func ApplyEdit(content string, e EditOp) (string, error) {
+	if e.OldString == "" {
+		return "", errors.New("old_string is empty")
+	}
+	if e.OldString == e.NewString {
+		return "", errors.New("old_string and new_string are identical")
+	}
+
+	if idx := indexAll(content, e.OldString); len(idx) > 0 {
+		if len(idx) > 1 && !e.ReplaceAll {
+			return "", ambiguousEditError(content, idx)
+		}
+		if e.ReplaceAll {
+			return strings.ReplaceAll(content, e.OldString, e.NewString), nil
+		}
+		return content[:idx[0]] + e.NewString + content[idx[0]+len(e.OldString):], nil
+	}
+
+	lines := strings.Split(content, "\n")
+	oldLines := splitLines(e.OldString)
+	if len(oldLines) == 0 {
+		return "", errors.New("old_string not found")
+	}
+	matches := normalizedMatches(lines, oldLines)
+	if len(matches) == 0 {
+		return "", errors.New("old_string not found (exact and whitespace-normalized match failed)")
+	}
+	if len(matches) > 1 && !e.ReplaceAll {
+		var at []string
+		for _, m := range matches {
+			at = append(at, fmt.Sprintf("%d", m+1))
+		}
+		return "", fmt.Errorf("old_string matches %d locations after whitespace normalization (lines %s); add surrounding context to make it unique or set replace_all", len(matches), strings.Join(at, ", "))
+	}
+
+	newLines := splitLines(e.NewString)
+	var out []string
+	prev := 0
+	for _, m := range matches {
+		out = append(out, lines[prev:m]...)
+		out = append(out, reindent(newLines, oldLines, lines[m:m+len(oldLines)])...)
+		prev = m + len(oldLines)
+	}
+	out = append(out, lines[prev:]...)
+	return strings.Join(out, "\n"), nil
+}
+
+func indexAll(s, sub string) []int {
+	var out []int
+	for start := 0; start <= len(s); {
+		i := strings.Index(s[start:], sub)
+		if i < 0 {
+			break
+		}
+		out = append(out, start+i)
+		start += i + len(sub)
+	}
+	return out
+}
+
+func ambiguousEditError(content string, idx []int) error {
+	var at []string
+	for _, i := range idx {
+		at = append(at, fmt.Sprintf("%d", strings.Count(content[:i], "\n")+1))
+	}
+	return fmt.Errorf("old_string matches %d locations (lines %s); add surrounding context to make it unique or set replace_all", len(idx), strings.Join(at, ", "))
+}
+
+func normalizedMatches(lines, oldLines []string) []int {
+	var out []int
+	for i := 0; i+len(oldLines) <= len(lines); i++ {
+		ok := true
+		for j, ol := range oldLines {
+			if strings.TrimSpace(lines[i+j]) != strings.TrimSpace(ol) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			out = append(out, i)
+			i += len(oldLines) - 1
+		}
+	}
+	return out
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+// reindent maps the indentation used in old_string onto the indentation of
+// the matched file lines, so a model that got tabs/spaces wrong still
+// produces consistently indented output.
+func reindent(newLines, oldLines, matched []string) []string {
+	indents := map[string]string{}
+	for i, ol := range oldLines {
+		from := leadingSpace(ol)
+		if _, ok := indents[from]; !ok && strings.TrimSpace(ol) != "" {
+			indents[from] = leadingSpace(matched[i])
+		}
+	}
+	from, to := leadingSpace(oldLines[0]), leadingSpace(matched[0])
+	out := make([]string, len(newLines))
+	for i, l := range newLines {
+		indent := leadingSpace(l)
+		switch mapped, ok := indents[indent]; {
+		case ok:
+			out[i] = mapped + l[len(indent):]
+		case strings.HasPrefix(l, from):
+			out[i] = to + l[len(from):]
+		default:
+			out[i] = l
+		}
+	}
+	return out
+}
+
+// EditsToWrites resolves edits against the files on disk so they can be
+// previewed or reviewed like full-file writes.
+func EditsToWrites(root string, edits []EditOp) ([]WriteOp, error) {
+	contents := map[string]string{}
+	var order []string
+	for _, e := range edits {
+		clean, err := safeRelPath(e.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Path, err)
+		}
+		cur, ok := contents[clean]
+		if !ok {
+			cur, err = readTextOrEmpty(filepath.Join(root, clean))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", clean, err)
+			}
+			order = append(order, clean)
+		}
+		updated, err := ApplyEdit(cur, e)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", clean, err)
+		}
+		contents[clean] = updated
+	}
+	var writes []WriteOp
+	for _, p := range order {
+		writes = append(writes, WriteOp{Path: p, Content: contents[p]})
+	}
+	return writes, nil
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyEditExact(t *testing.T) {
+	got, err := ApplyEdit("a\nb\nc\n", EditOp{OldString: "b\n", NewString: "B\n"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "a\nB\nc\n" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
+
+func TestApplyEditAmbiguous(t *testing.T) {
+	content := "x := 1\ny := 2\nx := 1\n"
+	_, err := ApplyEdit(content, EditOp{OldString: "x := 1", NewString: "x := 3"})
+	if err == nil || !strings.Contains(err.Error(), "matches 2 locations (lines 1, 3)") {
+		t.Fatalf("expected ambiguity error, got %v", err)
+	}
+	got, err := ApplyEdit(content, EditOp{OldString: "x := 1", NewString: "x := 3", ReplaceAll: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "x := 3\ny := 2\nx := 3\n" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
+
+func TestApplyEditNormalized(t *testing.T) {
+	content := "func f() {\n\tif ok {\n\t\treturn\n\t}\n}\n"
+	got, err := ApplyEdit(content, EditOp{
+		OldString: "if ok {\n    return\n}",
+		NewString: "if ok {\n    return nil\n}",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "func f() {\n\tif ok {\n\t\treturn nil\n\t}\n}\n"
+	if got != want {
+		t.Fatalf("unexpected content: %q", got)
+	}
+	if _, err := ApplyEdit(content, EditOp{OldString: "missing", NewString: "x"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestApplyChangeSetEdits(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{Edits: []EditOp{
+		{Path: "a.txt", OldString: "two", NewString: "three"},
+		{Path: "a.txt", OldString: "e", NewString: "E"},
+	}})
+	if report.Committed {
+		t.Fatalf("expected ambiguous edit to roll back")
+	}
+	if report.Results[1].Status != OpFailed || !strings.Contains(report.Results[1].Reason, "matches") {
+		t.Fatalf("unexpected result: %+v", report.Results[1])
+	}
+	report = ApplyChangeSet(root, ChangeSet{Edits: []EditOp{{Path: "a.txt", OldString: "two", NewString: "three"}}})
+	if !report.Committed || len(report.Edits) != 1 {
+		t.Fatalf("expected edit to apply: %+v", report)
+	}
+	b, _ := os.ReadFile(filepath.Join(root, "a.txt"))
+	if string(b) != "one\nthree\n" {
+		t.Fatalf("unexpected content: %q", b)
+	}
+}
@@ -0,0 +1,401 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/llm"
+)
+
+var ExportFormats = []string{"md", "html", "json"}
+
+// ExportOptions adds what only the caller knows: the TUI passes its action
+// log and the file refs it loaded, whose secrets are redacted by value.
+type ExportOptions struct {
+	Format  string
+	Actions []string
+	Refs    []FileRef
+}
+
+type exportDoc struct {
+	Session  Session      `json:"session"`
+	Exported string       `json:"exported"`
+	Turns    []exportTurn `json:"turns"`
+	Actions  []string     `json:"actions"`
+	Usage    exportUsage  `json:"usage"`
+}
+
+type exportTurn struct {
+	Time    string           `json:"time"`
+	Prompt  string           `json:"prompt"`
+	Message string           `json:"message,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	Files   []TranscriptFile `json:"files,omitempty"`
+	Changes []exportChange   `json:"changes,omitempty"`
+	Usage   *llm.Usage       `json:"usage,omitempty"`
+	TotalMs int64            `json:"total_ms"`
+}
+
+type exportChange struct {
+	Kind   string `json:"kind"`
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+type exportUsage struct {
+	Turns        int   `json:"turns"`
+	InputTokens  int   `json:"input_tokens"`
+	OutputTokens int   `json:"output_tokens"`
+	TotalTokens  int   `json:"total_tokens"`
+	TotalMs      int64 `json:"total_ms"`
+}
+
+func ParseExportFormat(s string) (string, bool) {
+	s = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), ".")
+	if s == "markdown" {
+		s = "md"
+	}
+	for _, f := range ExportFormats {
+		if s == f {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// DefaultExportPath is the file name used when no path is given.
+func DefaultExportPath(s Session, format string) string {
+	return "minibrain-" + s.ID + "." + format
+}
+
+// ExportSession renders a session's transcript as a standalone Markdown,
+// HTML or JSON document with secrets redacted.
+func ExportSession(cfg Config, s Session, opts ExportOptions) (string, error) {
+	format, ok := ParseExportFormat(opts.Format)
+	if opts.Format == "" {
+		format, ok = "md", true
+	}
+	if !ok {
+		return "", fmt.Errorf("unknown export format %q (use md, html or json)", opts.Format)
+	}
+	events, err := LoadTranscript(s.TranscriptPath(cfg.BrainDir))
+	if err != nil {
+		return "", err
+	}
+	if len(events) == 0 {
+		return "", errors.New("session " + s.ID + " has no transcript to export")
+	}
+	doc := buildExport(s, events, opts.Actions)
+	doc = doc.scrub(exportScrubber(cfg, opts.Refs))
+
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "\n", nil
+	case "html":
+		var b bytes.Buffer
+		if err := exportHTML.Execute(&b, doc); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+	return renderExportMarkdown(doc), nil
+}
+
+func buildExport(s Session, events []TranscriptEvent, actions []string) exportDoc {
+	doc := exportDoc{Session: s, Exported: time.Now().Format(time.RFC3339), Actions: actions}
+	for _, ev := range events {
+		switch ev.Type {
+		case EventTurn:
+			t := exportTurn{Time: ev.Time, Prompt: ev.Prompt, Error: ev.Error, Files: ev.Files, Usage: ev.Usage, TotalMs: ev.Timings.TotalMs}
+			if ev.Response != nil {
+				t.Message = ev.Response.Message
+			}
+			t.Changes = exportChanges(ev)
+			doc.Turns = append(doc.Turns, t)
+			doc.Usage.Turns++
+			if ev.Usage != nil {
+				doc.Usage.InputTokens += ev.Usage.InputTokens
+				doc.Usage.OutputTokens += ev.Usage.OutputTokens
+				doc.Usage.TotalTokens += ev.Usage.TotalTokens
+			}
+		case EventApply:
+			// A later apply settles the changes the previous turn proposed.
+			if n := len(doc.Turns); n > 0 {
+				for _, op := range ev.Ops {
+					for i, c := range doc.Turns[n-1].Changes {
+						if c.Kind == op.Kind && c.Path == op.Path {
+							doc.Turns[n-1].Changes[i].Status, doc.Turns[n-1].Changes[i].Reason = op.Status, op.Reason
+							if op.Diff != "" {
+								doc.Turns[n-1].Changes[i].Diff = op.Diff
+							}
+						}
+					}
+				}
+			}
+		}
+		doc.Usage.TotalMs += ev.Timings.TotalMs
+		if len(actions) == 0 {
+			for _, op := range ev.Ops {
+				line := ev.Time + " " + op.Kind + " " + op.Path + " " + op.Status
+				if op.Reason != "" {
+					line += " (" + op.Reason + ")"
+				}
+				doc.Actions = append(doc.Actions, line)
+			}
+		}
+	}
+	return doc
+}
+
+// exportChanges pairs each change the model proposed with its diff and the
+// status the turn recorded for it. An applied change shows the diff recorded
+// for the file when it was applied; the rest show what the model proposed.
+func exportChanges(ev TranscriptEvent) []exportChange {
+	status := map[string]TranscriptOp{}
+	for _, op := range ev.Ops {
+		status[op.Kind+" "+op.Path] = op
+	}
+	var out []exportChange
+	add := func(kind, path, diff string) {
+		c := exportChange{Kind: kind, Path: path, Status: "proposed", Diff: diff}
+		if op, ok := status[kind+" "+path]; ok {
+			c.Status, c.Reason = op.Status, op.Reason
+			if op.Diff != "" {
+				c.Diff = op.Diff
+			}
+			delete(status, kind+" "+path)
+		}
+		out = append(out, c)
+	}
+	if r := ev.Response; r != nil {
+		for _, w := range r.Writes {
+			add("WRITE", w.Path, UnifiedDiff(w.Path, "", w.Content, 3))
+		}
+		for _, d := range r.Deletes {
+			add("DELETE", d, "")
+		}
+		for _, p := range r.Patches {
+			add("PATCH", p.Path, p.Diff)
+		}
+		for _, e := range r.Edits {
+			add("EDIT", e.Path, UnifiedDiff(e.Path, e.OldString, e.NewString, 3))
+		}
+	}
+	for _, op := range ev.Ops {
+		if _, ok := status[op.Kind+" "+op.Path]; ok {
+			out = append(out, exportChange{Kind: op.Kind, Path: op.Path, Status: op.Status, Reason: op.Reason, Diff: op.Diff})
+		}
+	}
+	return out
+}
+
+// exportScrubber replaces secrets the session's file refs are known to hold,
+// then runs the usual detectors over whatever is left.
+func exportScrubber(cfg Config, refs []FileRef) func(string) string {
+	var pairs [][2]string
+	for _, ref := range refs {
+		for placeholder, secret := range ref.secrets {
+			if secret != "" {
+				pairs = append(pairs, [2]string{secret, placeholder})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return len(pairs[i][0]) > len(pairs[j][0]) })
+	var args []string
+	for _, p := range pairs {
+		args = append(args, p[0], p[1])
+	}
+	known := strings.NewReplacer(args...)
+	return func(s string) string {
+		if s == "" {
+			return s
+		}
+		out, _ := redactorFor(cfg).Redact("export", known.Replace(s))
+		return out
+	}
+}
+
+func (d exportDoc) scrub(f func(string) string) exportDoc {
+	d.Session.Title = f(d.Session.Title)
+	turns := make([]exportTurn, len(d.Turns))
+	for i, t := range d.Turns {
+		t.Prompt, t.Message, t.Error = f(t.Prompt), f(t.Message), f(t.Error)
+		changes := make([]exportChange, len(t.Changes))
+		for j, c := range t.Changes {
+			c.Reason, c.Diff = f(c.Reason), f(c.Diff)
+			changes[j] = c
+		}
+		t.Changes = changes
+		turns[i] = t
+	}
+	d.Turns = turns
+	actions := make([]string, len(d.Actions))
+	for i, a := range d.Actions {
+		actions[i] = f(a)
+	}
+	d.Actions = actions
+	return d
+}
+
+func renderExportMarkdown(d exportDoc) string {
+	var b strings.Builder
+	title := d.Session.Title
+	if title == "" {
+		title = d.Session.ID
+	}
+	b.WriteString("# minibrain session: " + title + "\n\n")
+	fmt.Fprintf(&b, "- Session: %s\n- Project: %s\n- Model: %s\n- Started: %s\n- Exported: %s\n\n", d.Session.ID, d.Session.Root, d.Session.Model, d.Session.Started, d.Exported)
+	for i, t := range d.Turns {
+		fmt.Fprintf(&b, "## Turn %d\n\n_%s_\n\n", i+1, t.Time)
+		b.WriteString("**Prompt**\n\n" + quote(t.Prompt) + "\n\n")
+		if t.Message != "" {
+			b.WriteString("**Response**\n\n" + strings.TrimSpace(t.Message) + "\n\n")
+		}
+		if t.Error != "" {
+			b.WriteString("**Error:** " + t.Error + "\n\n")
+		}
+		for _, f := range t.Files {
+			status := "loaded"
+			if f.Error != "" {
+				status = f.Error
+			}
+			fmt.Fprintf(&b, "- read `%s`: %s\n", f.Path, status)
+		}
+		if len(t.Files) > 0 {
+			b.WriteString("\n")
+		}
+		for _, c := range t.Changes {
+			fmt.Fprintf(&b, "### %s `%s` (%s)\n\n", c.Kind, c.Path, changeStatus(c))
+			if c.Diff != "" {
+				fence := codeFence(c.Diff)
+				b.WriteString(fence + "diff\n" + strings.TrimRight(c.Diff, "\n") + "\n" + fence + "\n\n")
+			}
+		}
+		if t.Usage != nil {
+			fmt.Fprintf(&b, "_%d input + %d output tokens, %s_\n\n", t.Usage.InputTokens, t.Usage.OutputTokens, time.Duration(t.TotalMs)*time.Millisecond)
+		}
+	}
+	if len(d.Actions) > 0 {
+		b.WriteString("## Action Log\n\n")
+		for _, a := range d.Actions {
+			b.WriteString("- " + a + "\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("## Usage\n\n")
+	fmt.Fprintf(&b, "- Turns: %d\n- Input tokens: %d\n- Output tokens: %d\n- Total tokens: %d\n- Time: %s\n", d.Usage.Turns, d.Usage.InputTokens, d.Usage.OutputTokens, d.Usage.TotalTokens, time.Duration(d.Usage.TotalMs)*time.Millisecond)
+	return b.String()
+}
+
+func changeStatus(c exportChange) string {
+	if c.Reason != "" {
+		return c.Status + ": " + c.Reason
+	}
+	return c.Status
+}
+
+func quote(s string) string {
+	return "> " + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n> ")
+}
+
+// codeFence returns a backtick fence longer than any run inside s.
+func codeFence(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+			continue
+		}
+		run = 0
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func diffLineClass(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return "file"
+	case strings.HasPrefix(line, "@@"):
+		return "hunk"
+	case strings.HasPrefix(line, "+"):
+		return "add"
+	case strings.HasPrefix(line, "-"):
+		return "del"
+	}
+	return ""
+}
+
+var exportHTML = template.Must(template.New("export").Funcs(template.FuncMap{
+	"lines":    func(s string) []string { return strings.Split(strings.TrimRight(s, "\n"), "\n") },
+	"class":    diffLineClass,
+	"status":   changeStatus,
+	"inc":      func(i int) int { return i + 1 },
+	"duration": func(ms int64) string { return (time.Duration(ms) * time.Millisecond).String() },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>minibrain session {{.Session.ID}}</title>
+<style>
+body { font: 15px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+h1 { font-size: 1.5em; } h2 { font-size: 1.2em; border-bottom: 1px solid #d0d7de; padding-bottom: .2em; margin-top: 2em; }
+.meta, .muted { color: #656d76; font-size: .9em; }
+.prompt { background: #f6f8fa; border-left: 4px solid #0969da; padding: .5em 1em; white-space: pre-wrap; }
+.message { white-space: pre-wrap; }
+.error { color: #cf222e; }
+details { border: 1px solid #d0d7de; border-radius: 6px; margin: .5em 0; }
+summary { cursor: pointer; padding: .4em .8em; background: #f6f8fa; font-family: ui-monospace, monospace; font-size: .9em; }
+pre { margin: 0; padding: .5em .8em; overflow-x: auto; font: 13px/1.4 ui-monospace, SFMono-Regular, Menlo, monospace; }
+pre span { display: block; white-space: pre; }
+.add { background: #dafbe1; } .del { background: #ffebe9; } .hunk { color: #8250df; } .file { color: #656d76; }
+.status { font-weight: 600; }
+ul.actions { font-family: ui-monospace, monospace; font-size: .85em; }
+table { border-collapse: collapse; } td { padding: .1em 1em .1em 0; }
+</style>
+</head>
+<body>
+<h1>minibrain session: {{if .Session.Title}}{{.Session.Title}}{{else}}{{.Session.ID}}{{end}}</h1>
+<p class="meta">Session {{.Session.ID}} · {{.Session.Root}} · {{.Session.Model}} · started {{.Session.Started}} · exported {{.Exported}}</p>
+{{range $i, $t := .Turns}}
+<h2>Turn {{inc $i}}</h2>
+<p class="muted">{{$t.Time}}</p>
+<div class="prompt">{{$t.Prompt}}</div>
+{{if $t.Message}}<div class="message">{{$t.Message}}</div>{{end}}
+{{if $t.Error}}<p class="error">{{$t.Error}}</p>{{end}}
+{{if $t.Files}}<ul>{{range $t.Files}}<li>read <code>{{.Path}}</code>: {{if .Error}}{{.Error}}{{else}}loaded{{end}}</li>{{end}}</ul>{{end}}
+{{range $t.Changes}}<details>
+<summary>{{.Kind}} {{.Path}} · <span class="status">{{status .}}</span></summary>
+{{if .Diff}}<pre>{{range lines .Diff}}<span class="{{class .}}">{{.}}</span>{{end}}</pre>{{end}}
+</details>
+{{end}}
+{{with $t.Usage}}<p class="muted">{{.InputTokens}} input + {{.OutputTokens}} output tokens, {{duration $t.TotalMs}}</p>{{end}}
+{{end}}
+{{if .Actions}}<h2>Action Log</h2>
+<ul class="actions">{{range .Actions}}<li>{{.}}</li>{{end}}</ul>{{end}}
+<h2>Usage</h2>
+<table>
+<tr><td>Turns</td><td>{{.Usage.Turns}}</td></tr>
+<tr><td>Input tokens</td><td>{{.Usage.InputTokens}}</td></tr>
+<tr><td>Output tokens</td><td>{{.Usage.OutputTokens}}</td></tr>
+<tr><td>Total tokens</td><td>{{.Usage.TotalTokens}}</td></tr>
+<tr><td>Time</td><td>{{duration .Usage.TotalMs}}</td></tr>
+</table>
+</body>
+</html>
+`))
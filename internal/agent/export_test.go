@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/llm"
+)
+
+func exportSession(t *testing.T) (Config, Session) {
+	t.Helper()
+	brainDir := t.TempDir()
+	s, err := NewSession(brainDir, "/tmp/project", "gpt-4.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := s.TranscriptPath(brainDir)
+	turn := TranscriptEvent{
+		Type:   EventTurn,
+		Prompt: "update the config",
+		Response: &StructuredResponse{
+			Message: "Set the db password to plainvalue9 and added a note.",
+			Patches: []StructuredPatch{{Path: "config.yml", Diff: "--- a/config.yml\n+++ b/config.yml\n@@ -1 +1 @@\n-old: 1\n+new: 2\n"}},
+			Writes:  []StructuredWrite{{Path: "NOTES.md", Content: "use ``` fences\n"}},
+		},
+		Ops:   []TranscriptOp{{Kind: "PATCH", Path: "config.yml", Status: "proposed"}, {Kind: "WRITE", Path: "NOTES.md", Status: "proposed"}},
+		Usage: &llm.Usage{InputTokens: 100, OutputTokens: 20, TotalTokens: 120},
+	}
+	turn.Timings.TotalMs = 1500
+	if err := AppendTranscript(path, turn); err != nil {
+		t.Fatal(err)
+	}
+	report := ChangeReport{Results: []OpResult{{Kind: "PATCH", Path: "config.yml", Status: OpApplied}, {Kind: "WRITE", Path: "NOTES.md", Status: OpFailed, Reason: "permission denied"}}}
+	if err := RecordApply(Config{}, s.PrefrontalPath(brainDir), report, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	return Config{BrainDir: brainDir}, s
+}
+
+func TestExportFormats(t *testing.T) {
+	cfg, s := exportSession(t)
+	refs := []FileRef{{Path: ".env", secrets: map[string]string{"[REDACTED:custom:0011aabb]": "plainvalue9"}}}
+
+	md, err := ExportSession(cfg, s, ExportOptions{Format: "md", Refs: refs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Turn 1", "> update the config", "### PATCH `config.yml` (applied)", "````diff", "+new: 2", "(failed: permission denied)", "## Action Log", "- Total tokens: 120"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "plainvalue9") || !strings.Contains(md, "[REDACTED:custom:0011aabb]") {
+		t.Fatalf("expected the known secret to be redacted:\n%s", md)
+	}
+
+	page, err := ExportSession(cfg, s, ExportOptions{Format: "html", Refs: refs, Actions: []string{"PATCH: config.yml"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<style>", "<details>", `<span class="add">&#43;new: 2</span>`, "PATCH: config.yml"} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("html missing %q", want)
+		}
+	}
+	if strings.Contains(page, "<link") || strings.Contains(page, "<script") || strings.Contains(page, "plainvalue9") {
+		t.Fatal("expected a self-contained, redacted page")
+	}
+
+	out, err := ExportSession(cfg, s, ExportOptions{Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc exportDoc
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(doc.Turns) != 1 || len(doc.Turns[0].Changes) != 2 || doc.Usage.TotalTokens != 120 || len(doc.Actions) != 4 {
+		t.Fatalf("unexpected export %+v", doc)
+	}
+
+	if _, err := ExportSession(cfg, s, ExportOptions{Format: "pdf"}); err == nil {
+		t.Fatal("expected an unknown format error")
+	}
+}
+
+func TestExportShowsAppliedDiff(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("old line\nkept\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	brainDir := t.TempDir()
+	s, err := NewSession(brainDir, root, "gpt-4.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := TranscriptEvent{
+		Type:     EventTurn,
+		Response: &StructuredResponse{Writes: []StructuredWrite{{Path: "a.txt", Content: "new line\nkept\n"}}},
+		Ops:      []TranscriptOp{{Kind: "WRITE", Path: "a.txt", Status: "proposed"}},
+	}
+	if err := AppendTranscript(s.TranscriptPath(brainDir), turn); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{BrainDir: brainDir, RedactPatterns: []string{`kept`}}
+	report := ApplyChangeSet(root, ChangeSet{Writes: []WriteOp{{Path: "a.txt", Content: "new line\nkept\n"}}})
+	if err := RecordApply(cfg, s.PrefrontalPath(brainDir), report, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	md, err := ExportSession(cfg, s, ExportOptions{Format: "md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md, "-old line") || !strings.Contains(md, "+new line") || strings.Contains(md, "+kept") || strings.Contains(md, " kept") {
+		t.Fatalf("expected the applied, redacted diff:\n%s", md)
+	}
+	if _, err := os.Stat(RedactionLogPath(brainDir)); err == nil {
+		t.Fatal("export should not log redactions")
+	}
+}
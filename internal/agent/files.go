@@ -18,8 +18,7 @@ func ListFiles(root string, maxFiles int) ([]string, bool) {
 			return nil
 		}
 		if d.IsDir() {
-			name := d.Name()
-			if name == ".git" || name == "node_modules" || name == "vendor" || name == "dist" || name == "build" || name == "bin" || name == "tmp" {
+			if skipWalkDir(d.Name()) {
 				return filepath.SkipDir
 			}
 			return nil
@@ -62,8 +61,7 @@ func ListRelevantFiles(root, prompt string, maxFiles int) ([]string, bool) {
 			return nil
 		}
 		if d.IsDir() {
-			name := d.Name()
-			if name == ".git" || name == "node_modules" || name == "vendor" || name == "dist" || name == "build" || name == "bin" || name == "tmp" {
+			if skipWalkDir(d.Name()) {
 				return filepath.SkipDir
 			}
 			return nil
@@ -111,6 +109,14 @@ func ListRelevantFiles(root, prompt string, maxFiles int) ([]string, bool) {
 	return files, truncated
 }
 
+func skipWalkDir(name string) bool {
+	switch name {
+	case ".git", ".minibrain", "node_modules", "vendor", "dist", "build", "bin", "tmp":
+		return true
+	}
+	return false
+}
+
 func promptTokens(prompt string) []string {
 	parts := strings.FieldsFunc(strings.ToLower(prompt), func(r rune) bool {
 		return !isTokenChar(r)
@@ -0,0 +1,329 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/git"
+	"github.com/chrishannah/minibrain/internal/llm"
+)
+
+type GitOptions struct {
+	Enabled bool
+	Branch  bool
+	Stash   bool
+}
+
+type GitResult struct {
+	Branch  string
+	Stashed bool
+	Commit  string
+	Message string
+	Err     error
+}
+
+func ResolveGitOptions(root string, envEnabled bool) GitOptions {
+	// An unreadable config leaves git mode to the env var; applying fails
+	// on it anyway.
+	proj, _ := LoadProjectConfig(root)
+	return GitOptions{
+		Enabled: envEnabled || proj.GitMode,
+		Branch:  proj.GitBranch,
+		Stash:   proj.GitStash,
+	}
+}
+
+// ApplyChanges applies set like ApplyChangeSet. In git mode (cfg.Git) it
+// first makes sure the worktree is clean (stashing if allowed), optionally
+// switches to a minibrain/<topic> branch, and commits the applied files
+// afterwards. A branch created for changes that were not applied is removed.
+func ApplyChanges(root string, set ChangeSet, cfg Config, prompt string) ChangeReport {
+	opts := cfg.Git
+	if !opts.Enabled || set.Empty() {
+		return ApplyChangeSet(root, set)
+	}
+	repo, err := git.Open(root)
+	if err != nil {
+		report := ApplyChangeSet(root, set)
+		report.Git = &GitResult{Err: err}
+		return report
+	}
+	result := &GitResult{}
+	_ = repo.EnsureExcluded(".minibrain/")
+
+	paths := repoPaths(repo, root, changeSetPaths(set))
+	dirty, err := repo.DirtyPaths()
+	if err != nil {
+		return gitRefused(set, result, err)
+	}
+	if len(dirty) > 0 {
+		if overlap := intersect(dirty, paths); len(overlap) > 0 {
+			return gitRefused(set, result, fmt.Errorf("uncommitted changes in files being changed: %s; commit or stash them first", strings.Join(overlap, ", ")))
+		}
+		if !opts.Stash {
+			return gitRefused(set, result, fmt.Errorf("worktree has uncommitted changes (%s); commit them or enable git_stash", strings.Join(dirty, ", ")))
+		}
+		stashed, err := repo.Stash("minibrain: before applying changes")
+		if err != nil {
+			return gitRefused(set, result, err)
+		}
+		result.Stashed = stashed
+	}
+
+	var original string
+	if opts.Branch {
+		// A detached HEAD has no branch to return to, so it is left alone.
+		if cur, err := repo.CurrentBranch(); err == nil && cur != "HEAD" && !strings.HasPrefix(cur, "minibrain/") {
+			name := uniqueBranch(repo, git.BranchName(FirstLine(prompt)))
+			if err := repo.CreateBranch(name); err != nil {
+				result.Err = err
+			} else {
+				original = cur
+				result.Branch = name
+			}
+		}
+	}
+
+	report := ApplyChangeSet(root, set)
+	if report.Committed && result.Err == nil {
+		result.Commit, result.Message, result.Err = commitPaths(repo, cfg, prompt, paths)
+	}
+	if !report.Committed && result.Branch != "" {
+		if err := repo.Checkout(original); err != nil {
+			result.Err = errors.Join(result.Err, fmt.Errorf("switching back to %s failed: %w", original, err))
+		} else if err := repo.DeleteBranch(result.Branch); err != nil {
+			result.Err = errors.Join(result.Err, err)
+		} else {
+			result.Branch = ""
+		}
+	}
+	if result.Stashed {
+		if err := repo.StashPop(); err != nil {
+			stashErr := fmt.Errorf("restoring stashed changes failed, they are still in `git stash list`: %w", err)
+			result.Err = errors.Join(result.Err, stashErr)
+		}
+	}
+	report.Git = result
+	return report
+}
+
+// CommitAll stages every change in the worktree and commits it. An empty
+// message is generated by the model from the diff.
+func CommitAll(root string, cfg Config, message string) (GitResult, error) {
+	repo, err := git.Open(root)
+	if err != nil {
+		return GitResult{}, err
+	}
+	_ = repo.EnsureExcluded(".minibrain/")
+	dirty, err := repo.DirtyPaths()
+	if err != nil {
+		return GitResult{}, err
+	}
+	if len(dirty) == 0 {
+		return GitResult{}, errors.New("nothing to commit")
+	}
+	if err := repo.Add(); err != nil {
+		return GitResult{}, err
+	}
+	var res GitResult
+	if strings.TrimSpace(message) == "" {
+		diff, err := repo.Diff(true)
+		if err != nil {
+			return GitResult{}, err
+		}
+		message = CommitMessage(cfg, "", diff)
+	}
+	res.Message = message
+	res.Commit, err = repo.Commit(message)
+	return res, err
+}
+
+// excludeFromGit adds dir, a .minibrain dir, to the exclude file of the
+// repo it is in. Outside a repo, or without git, there is nothing to do.
+func excludeFromGit(dir string) {
+	if !git.Available() {
+		return
+	}
+	if repo, err := git.Open(filepath.Dir(dir)); err == nil {
+		_ = repo.EnsureExcluded(".minibrain/")
+	}
+}
+
+func WorktreeDiff(root string) (string, error) {
+	repo, err := git.Open(root)
+	if err != nil {
+		return "", err
+	}
+	return repo.Diff(false)
+}
+
+func commitPaths(repo git.Repo, cfg Config, prompt string, paths []string) (string, string, error) {
+	if err := repo.Add(paths...); err != nil {
+		return "", "", err
+	}
+	diff, err := repo.Diff(true, paths...)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", "", nil
+	}
+	message := CommitMessage(cfg, prompt, diff)
+	hash, err := repo.Commit(message)
+	return hash, message, err
+}
+
+const maxCommitDiffBytes = 12000
+
+// CommitMessage asks cfg.Model for a commit message describing diff, falling
+// back to one derived from the prompt when the call fails. The diff is
+// redacted with cfg's patterns before it is sent.
+func CommitMessage(cfg Config, prompt, diff string) string {
+	diff, _ = redactorFor(cfg).Redact("commit diff", diff)
+	if len(diff) > maxCommitDiffBytes {
+		diff = diff[:maxCommitDiffBytes] + "\n... (truncated)\n"
+	}
+	dev := "Write a git commit message for the diff below. Use an imperative subject line under 72 characters, " +
+		"optionally followed by a blank line and a short body. Return only the message, no code fences."
+	user := "Diff:\n" + diff
+	if strings.TrimSpace(prompt) != "" {
+		user = "Request:\n" + prompt + "\n\n" + user
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	out, err := llm.CallOpenAIText(ctx, cfg.Model, dev, user)
+	if msg := cleanCommitMessage(out); err == nil && msg != "" {
+		return msg
+	}
+	subject := FirstLine(prompt)
+	if subject == "" {
+		return "minibrain: update files"
+	}
+	if len(subject) > 60 {
+		subject = strings.TrimSpace(subject[:60]) + "..."
+	}
+	return "minibrain: " + subject
+}
+
+func cleanCommitMessage(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+	return strings.TrimSpace(s)
+}
+
+// FirstLine returns the first line of s, trimmed, such as a commit
+// subject.
+func FirstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func uniqueBranch(repo git.Repo, name string) string {
+	candidate := name
+	for i := 2; repo.BranchExists(candidate); i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	return candidate
+}
+
+func gitRefused(set ChangeSet, result *GitResult, err error) ChangeReport {
+	result.Err = err
+	report := ChangeReport{Git: result, Err: fmt.Errorf("git mode: %w", err)}
+	for _, p := range changeSetPaths(set) {
+		report.Results = append(report.Results, OpResult{Kind: "CHANGE", Path: p, Status: OpSkipped, Reason: "git mode refused"})
+	}
+	return report
+}
+
+func changeSetPaths(set ChangeSet) []string {
+	seen := map[string]bool{}
+	var out []string
+	add := func(p string) {
+		clean, err := safeRelPath(p)
+		if err != nil || seen[clean] {
+			return
+		}
+		seen[clean] = true
+		out = append(out, clean)
+	}
+	for _, w := range set.Writes {
+		add(w.Path)
+	}
+	for _, d := range set.Deletes {
+		add(d.Path)
+	}
+	for _, p := range set.Patches {
+		add(p.Path)
+	}
+	for _, e := range set.Edits {
+		add(e.Path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// repoPaths converts root-relative paths to paths relative to the repo top
+// level, which is what git status reports.
+func repoPaths(repo git.Repo, root string, paths []string) []string {
+	top, err := filepath.EvalSymlinks(repo.Dir)
+	if err != nil {
+		top = repo.Dir
+	}
+	base, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		base = root
+	}
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rel, err := filepath.Rel(top, filepath.Join(base, p))
+		if err != nil {
+			rel = p
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	return out
+}
+
+func intersect(a, b []string) []string {
+	set := map[string]bool{}
+	for _, x := range a {
+		set[x] = true
+	}
+	var out []string
+	for _, x := range b {
+		if set[x] {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func FormatGitSummary(r *GitResult) string {
+	if r == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n## Git\n")
+	if r.Branch != "" {
+		b.WriteString("- Branch: " + r.Branch + "\n")
+	}
+	if r.Stashed {
+		b.WriteString("- Stashed uncommitted changes while applying\n")
+	}
+	if r.Commit != "" {
+		b.WriteString("- Commit: " + r.Commit + " " + FirstLine(r.Message) + "\n")
+	}
+	if r.Err != nil {
+		b.WriteString("- Error: " + r.Err.Error() + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("MINIBRAIN_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		gitRun(t, dir, args...)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "a.txt")
+	gitRun(t, dir, "commit", "-q", "-m", "init")
+	return dir
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v %s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestApplyChangesCommitsOnBranch(t *testing.T) {
+	root := gitRepo(t)
+	report := ApplyChanges(root, ChangeSet{Writes: []WriteOp{{Path: "a.txt", Content: "two\n"}}},
+		Config{Git: GitOptions{Enabled: true, Branch: true}}, "Update a.txt")
+	if !report.Committed || report.Git == nil || report.Git.Err != nil {
+		t.Fatalf("unexpected report: %+v %+v", report, report.Git)
+	}
+	if report.Git.Branch != "minibrain/update-a-txt" || report.Git.Commit == "" {
+		t.Fatalf("unexpected git result: %+v", report.Git)
+	}
+	if log := gitRun(t, root, "log", "-1", "--format=%s"); strings.TrimSpace(log) != "minibrain: Update a.txt" {
+		t.Fatalf("unexpected commit subject: %q", log)
+	}
+	if status := gitRun(t, root, "status", "--porcelain"); status != "" {
+		t.Fatalf("expected clean worktree, got %q", status)
+	}
+}
+
+func TestApplyChangesRefusesDirtyWorktree(t *testing.T) {
+	root := gitRepo(t)
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report := ApplyChanges(root, ChangeSet{Writes: []WriteOp{{Path: "a.txt", Content: "two\n"}}},
+		Config{Git: GitOptions{Enabled: true, Stash: true}}, "Update a.txt")
+	if report.Committed || report.Err == nil || !strings.Contains(report.Err.Error(), "a.txt") {
+		t.Fatalf("expected refusal, got %+v", report)
+	}
+	b, _ := os.ReadFile(filepath.Join(root, "a.txt"))
+	if string(b) != "local\n" {
+		t.Fatalf("file should be untouched, got %q", b)
+	}
+}
+
+func TestApplyChangesStashesUnrelatedChanges(t *testing.T) {
+	root := gitRepo(t)
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("wip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report := ApplyChanges(root, ChangeSet{Writes: []WriteOp{{Path: "a.txt", Content: "two\n"}}},
+		Config{Git: GitOptions{Enabled: true, Stash: true}}, "Update a.txt")
+	if !report.Committed || report.Git.Err != nil || !report.Git.Stashed {
+		t.Fatalf("unexpected result: %+v %+v", report, report.Git)
+	}
+	if files := gitRun(t, root, "show", "--name-only", "--format=", "HEAD"); strings.TrimSpace(files) != "a.txt" {
+		t.Fatalf("commit should only contain a.txt, got %q", files)
+	}
+	b, _ := os.ReadFile(filepath.Join(root, "notes.txt"))
+	if string(b) != "wip\n" {
+		t.Fatalf("stashed file not restored: %q", b)
+	}
+}
+
+func TestApplyChangesRemovesBranchWhenNothingApplied(t *testing.T) {
+	root := gitRepo(t)
+	before := strings.TrimSpace(gitRun(t, root, "rev-parse", "--abbrev-ref", "HEAD"))
+	report := ApplyChanges(root, ChangeSet{Edits: []EditOp{{Path: "a.txt", OldString: "missing", NewString: "two"}}},
+		Config{Git: GitOptions{Enabled: true, Branch: true}}, "Update a.txt")
+	if report.Committed || report.Git == nil || report.Git.Err != nil || report.Git.Branch != "" {
+		t.Fatalf("unexpected report: %+v %+v", report, report.Git)
+	}
+	if cur := strings.TrimSpace(gitRun(t, root, "rev-parse", "--abbrev-ref", "HEAD")); cur != before {
+		t.Fatalf("expected to be back on %s, got %s", before, cur)
+	}
+	if branches := gitRun(t, root, "branch", "--list", "minibrain/*"); branches != "" {
+		t.Fatalf("branch left behind: %q", branches)
+	}
+}
+
+func TestApplyChangesOnDetachedHead(t *testing.T) {
+	root := gitRepo(t)
+	gitRun(t, root, "checkout", "-q", "--detach")
+	report := ApplyChanges(root, ChangeSet{Writes: []WriteOp{{Path: "a.txt", Content: "two\n"}}},
+		Config{Git: GitOptions{Enabled: true, Branch: true}}, "Update a.txt")
+	if !report.Committed || report.Git == nil || report.Git.Err != nil || report.Git.Branch != "" {
+		t.Fatalf("unexpected report: %+v %+v", report, report.Git)
+	}
+	if branches := gitRun(t, root, "branch", "--list", "minibrain/*"); branches != "" {
+		t.Fatalf("no branch should be made from a detached HEAD: %q", branches)
+	}
+}
+
+func TestProjectDirExcludedFromGit(t *testing.T) {
+	root := gitRepo(t)
+	if err := AppendAudit(root, AuditEntry{Action: AuditRead, Path: "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if status := gitRun(t, root, "status", "--porcelain", "--untracked-files=all"); status != "" {
+		t.Fatalf("expected .minibrain to be ignored, got %q", status)
+	}
+}
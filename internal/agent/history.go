@@ -0,0 +1,261 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// BrainVersion records one rewrite of a brain file. Snapshot holds the
+// content from before the rewrite, so restoring a version undoes it.
+type BrainVersion struct {
+	TS       string `json:"ts"`
+	Path     string `json:"path"`
+	Snapshot string `json:"snapshot"`
+	Reason   string `json:"reason"`
+	Added    int    `json:"added"`
+	Removed  int    `json:"removed"`
+	Diff     string `json:"diff,omitempty"`
+}
+
+// Version limits are per file. CONTEXT.md is trimmed on every turn once it
+// reaches its budget, so those trims get a smaller quota of their own.
+const (
+	maxBrainVersions  = 200
+	maxTrimVersions   = 20
+	maxHistoryDiffLen = 20000
+)
+
+// historyDir returns where versions of path are kept: cortex/.history in the
+// brain dir, or .minibrain/.history for a project's memory.
+func historyDir(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) == ".minibrain" {
+		return filepath.Join(dir, ".history")
+	}
+	for d, i := dir, 0; i < 3; d, i = filepath.Dir(d), i+1 {
+		if filepath.Base(d) == "cortex" {
+			return filepath.Join(d, ".history")
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dir, "cortex")); err == nil && info.IsDir() {
+		return filepath.Join(dir, "cortex", ".history")
+	}
+	return filepath.Join(dir, ".history")
+}
+
+// writeBrainFile replaces a brain file, first snapshotting the previous
+// content and logging the diff under the history dir.
+func writeBrainFile(path, content, reason string) error {
+	return withFileLock(path, func() error {
+		return writeBrainFileLocked(path, content, reason)
+	})
+}
+
+// writeBrainFileLocked is writeBrainFile for callers that already hold the
+// lock for path.
+func writeBrainFileLocked(path, content, reason string) error {
+	old, err := vault.ReadFile(path)
+	existed := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if existed && string(old) == content {
+		return nil
+	}
+	if err := ensurePrivateDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if existed {
+		if err := recordBrainVersion(path, string(old), content, reason); err != nil {
+			return fmt.Errorf("failed to record history for %s: %w", filepath.Base(path), err)
+		}
+	}
+	return atomicWriteFile(path, []byte(content), 0600)
+}
+
+func recordBrainVersion(path, old, updated, reason string) error {
+	dir := historyDir(path)
+	logPath := filepath.Join(dir, "log.jsonl")
+	return withFileLock(logPath, func() error {
+		return recordBrainVersionLocked(dir, logPath, path, old, updated, reason)
+	})
+}
+
+func recordBrainVersionLocked(dir, logPath, path, old, updated, reason string) error {
+	var ts string
+	for {
+		ts = time.Now().UTC().Format("20060102T150405.000000Z")
+		err := os.Mkdir(filepath.Join(dir, ts), 0700)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		time.Sleep(time.Microsecond)
+	}
+	snapshot := filepath.Join(ts, filepath.Base(path))
+	if err := atomicWriteFile(filepath.Join(dir, snapshot), []byte(old), 0600); err != nil {
+		return err
+	}
+	v := BrainVersion{TS: ts, Path: path, Snapshot: snapshot, Reason: reason}
+	v.Added, v.Removed = DiffStats(old, updated)
+	v.Diff = UnifiedDiff(filepath.Base(path), old, updated, 2)
+	if len(v.Diff) > maxHistoryDiffLen {
+		v.Diff = v.Diff[:maxHistoryDiffLen] + "\n... (truncated)\n"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := appendFile(logPath, append(b, '\n')); err != nil {
+		return err
+	}
+	return pruneBrainHistory(dir)
+}
+
+// pruneBrainHistory keeps the most recent maxBrainVersions versions of each
+// file, and maxTrimVersions of its trims, so a busy file cannot push out the
+// history of the others.
+func pruneBrainHistory(dir string) error {
+	versions, err := readBrainHistory(dir)
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	var keep, drop []BrainVersion
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		key, limit := v.Path, maxBrainVersions
+		if v.Reason == "trim" {
+			key, limit = v.Path+"\x00trim", maxTrimVersions
+		}
+		counts[key]++
+		if counts[key] > limit {
+			drop = append(drop, v)
+			continue
+		}
+		keep = append(keep, v)
+	}
+	if len(drop) == 0 {
+		return nil
+	}
+	for _, v := range drop {
+		_ = os.RemoveAll(filepath.Join(dir, v.TS))
+	}
+	var b bytes.Buffer
+	for i := len(keep) - 1; i >= 0; i-- {
+		line, err := json.Marshal(keep[i])
+		if err != nil {
+			return err
+		}
+		b.Write(append(line, '\n'))
+	}
+	return atomicWriteFile(filepath.Join(dir, "log.jsonl"), b.Bytes(), 0600)
+}
+
+func readBrainHistory(dir string) ([]BrainVersion, error) {
+	b, err := vault.ReadFile(filepath.Join(dir, "log.jsonl"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []BrainVersion
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var v BrainVersion
+		if err := json.Unmarshal(sc.Bytes(), &v); err != nil {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out, sc.Err()
+}
+
+func historyDirs(cfg Config) []string {
+	dirs := []string{filepath.Join(cfg.BrainDir, "cortex", ".history")}
+	if path := resolveProjectNeoPath(cfg); path != "" {
+		dirs = append(dirs, historyDir(path))
+	}
+	return dirs
+}
+
+// ListBrainHistory returns the versions of the user's brain files and the
+// project memory, oldest first.
+func ListBrainHistory(cfg Config) ([]BrainVersion, error) {
+	var out []BrainVersion
+	for _, dir := range historyDirs(cfg) {
+		versions, err := readBrainHistory(dir)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, versions...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].TS < out[j].TS })
+	return out, nil
+}
+
+// RestoreBrainVersion puts back the content a file had just before the
+// rewrite at ts. The restore is itself recorded, so it can be undone too.
+func RestoreBrainVersion(cfg Config, ts string) (BrainVersion, error) {
+	ts = strings.TrimSpace(ts)
+	for _, dir := range historyDirs(cfg) {
+		versions, err := readBrainHistory(dir)
+		if err != nil {
+			return BrainVersion{}, err
+		}
+		for _, v := range versions {
+			if v.TS != ts {
+				continue
+			}
+			b, err := vault.ReadFile(filepath.Join(dir, v.Snapshot))
+			if err != nil {
+				return BrainVersion{}, fmt.Errorf("snapshot for %s is missing: %w", ts, err)
+			}
+			if err := writeBrainFile(v.Path, string(b), "restore "+ts); err != nil {
+				return BrainVersion{}, err
+			}
+			if filepath.Base(v.Path) == "NEO.md" {
+				scope := ScopeUser
+				if v.Path == resolveProjectNeoPath(cfg) {
+					scope = ScopeProject
+				}
+				if _, err := LoadMemories(v.Path, scope); err != nil {
+					return v, err
+				}
+			}
+			return v, nil
+		}
+	}
+	return BrainVersion{}, fmt.Errorf("no history entry %s", ts)
+}
+
+func FormatBrainVersion(v BrainVersion) string {
+	return fmt.Sprintf("%s  %s  %s (+%d -%d)", v.TS, shortBrainPath(v.Path), v.Reason, v.Added, v.Removed)
+}
+
+// shortBrainPath shows session files as sessions/<id>/<name> and other
+// files by name.
+func shortBrainPath(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(filepath.Dir(dir)) == "sessions" {
+		return filepath.Join("sessions", filepath.Base(dir), filepath.Base(path))
+	}
+	if filepath.Base(dir) == ".minibrain" {
+		return filepath.Join(".minibrain", filepath.Base(path))
+	}
+	return filepath.Base(path)
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBrainHistoryAndRestore(t *testing.T) {
+	cfg := Config{RootDir: t.TempDir(), BrainDir: t.TempDir()}
+	pre := filepath.Join(cfg.BrainDir, "cortex", "PREFRONTAL.md")
+	if err := writeBrainFile(pre, "# STM\n\n- detailed notes\n", "init"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if versions, _ := ListBrainHistory(cfg); len(versions) != 0 {
+		t.Fatalf("creating a file should not record a version, got %d", len(versions))
+	}
+	if err := ClearShortTerm(Config{BrainDir: cfg.BrainDir, PrefrontalPath: pre}); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if err := writeBrainFile(pre, "unchanged", "x"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := writeBrainFile(pre, "unchanged", "x"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	versions, err := ListBrainHistory(cfg)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d (%v)", len(versions), err)
+	}
+	first := versions[0]
+	if first.Reason != "clear" || first.Removed == 0 || !strings.Contains(first.Diff, "-- detailed notes") {
+		t.Fatalf("unexpected version: %#v", first)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.BrainDir, "cortex", ".history", first.Snapshot)); err != nil {
+		t.Fatalf("expected snapshot: %v", err)
+	}
+
+	if _, err := RestoreBrainVersion(cfg, first.TS); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	b, _ := os.ReadFile(pre)
+	if string(b) != "# STM\n\n- detailed notes\n" {
+		t.Fatalf("unexpected restored content: %q", string(b))
+	}
+	versions, _ = ListBrainHistory(cfg)
+	if len(versions) != 3 || versions[2].Reason != "restore "+first.TS {
+		t.Fatalf("expected restore to be recorded, got %#v", versions)
+	}
+	if _, err := RestoreBrainVersion(cfg, "nope"); err == nil {
+		t.Fatal("expected error for unknown version")
+	}
+}
+
+func TestProjectNeoHistory(t *testing.T) {
+	cfg := Config{RootDir: t.TempDir(), BrainDir: t.TempDir()}
+	if _, err := RememberFact(cfg, "project: Uses Go modules"); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	e, err := RememberFact(cfg, "project: Builds with make")
+	if err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	versions, _ := ListBrainHistory(cfg)
+	if len(versions) != 1 || historyDir(versions[0].Path) != filepath.Join(cfg.RootDir, ".minibrain", ".history") {
+		t.Fatalf("unexpected project history: %#v", versions)
+	}
+	if _, err := RestoreBrainVersion(cfg, versions[0].TS); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	entries, _ := LoadMemories(ProjectNeoPath(cfg.RootDir), ScopeProject)
+	if len(entries) != 1 || findMemory(entries, e.Fact) >= 0 {
+		t.Fatalf("expected store to follow restored NEO, got %#v", entries)
+	}
+}
+
+func TestBrainHistoryCapIsPerFile(t *testing.T) {
+	cfg := Config{RootDir: t.TempDir(), BrainDir: t.TempDir()}
+	neo := filepath.Join(cfg.BrainDir, "cortex", "NEO.md")
+	ctx := filepath.Join(cfg.BrainDir, "cortex", "CONTEXT.md")
+	for i, content := range []string{"one\n", "two\n"} {
+		if err := writeBrainFile(neo, content, "edit"); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+	for i := 0; i <= maxTrimVersions+5; i++ {
+		if err := writeBrainFile(ctx, strings.Repeat("x", i+1), "trim"); err != nil {
+			t.Fatalf("trim %d: %v", i, err)
+		}
+	}
+	versions, err := ListBrainHistory(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trims, neoVersions := 0, 0
+	for _, v := range versions {
+		switch v.Path {
+		case ctx:
+			trims++
+		case neo:
+			neoVersions++
+		}
+	}
+	if trims != maxTrimVersions || neoVersions != 1 {
+		t.Fatalf("expected %d trims and the NEO.md version kept, got %d and %d", maxTrimVersions, trims, neoVersions)
+	}
+}
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"time"
@@ -22,9 +23,10 @@ func EnsureBrainLayout(brainDir, repoRoot string) error {
 	if brainDir == "" {
 		return errors.New("brain dir is required")
 	}
-	if err := os.MkdirAll(filepath.Join(brainDir, "cortex"), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Join(brainDir, "cortex"), 0700); err != nil {
 		return err
 	}
+	tightenPermissions(brainDir)
 
 	// Migrate or seed MINIBRAIN.md
 	minibrainDst := filepath.Join(brainDir, "MINIBRAIN.md")
@@ -53,12 +55,39 @@ func EnsureBrainLayout(brainDir, repoRoot string) error {
 	// Migrate or seed CONTEXT.md
 	contextDst := filepath.Join(brainDir, "cortex", "CONTEXT.md")
 	if !fileExists(contextDst) {
-		_ = os.WriteFile(contextDst, []byte(defaultContext()), 0644)
+		_ = writeBrainFile(contextDst, defaultContext(), "init")
 	}
 
 	return nil
 }
 
+// tightenPermissions makes the brain dir private to the user. Older
+// versions created it with 0755 dirs and 0644 files; the dir itself is
+// fixed last, so a finished pass is not repeated.
+func tightenPermissions(brainDir string) {
+	if info, err := os.Stat(brainDir); err != nil || info.Mode().Perm() == 0700 {
+		return
+	}
+	_ = filepath.WalkDir(brainDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == brainDir {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		want := info.Mode().Perm() &^ 0077
+		if d.IsDir() {
+			want = 0700
+		}
+		if info.Mode().Perm() != want {
+			_ = os.Chmod(path, want)
+		}
+		return nil
+	})
+	_ = os.Chmod(brainDir, 0700)
+}
+
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
@@ -67,18 +96,18 @@ func fileExists(path string) bool {
 func copyDefault(repoRoot, repoRel, dst, fallback string) {
 	if repoRoot != "" {
 		if b, err := os.ReadFile(filepath.Join(repoRoot, repoRel)); err == nil {
-			_ = os.WriteFile(dst, b, 0644)
+			_ = writeBrainFile(dst, string(b), "init")
 			return
 		}
 	}
-	_ = os.WriteFile(dst, []byte(fallback), 0644)
+	_ = writeBrainFile(dst, fallback, "init")
 }
 
 func defaultMinibrain() string {
 	return "# MINIBRAIN\n\n" +
 		"Core wiring for the agent. Keep this file small and focused on behavior and memory wiring.\n\n" +
 		"## Memory Files\n" +
-		"- Long-term memory: `cortex/NEO.md`\n" +
+		"- Long-term memory: `cortex/NEO.md` (user-wide) and `.minibrain/NEO.md` in each project\n" +
 		"- Short-term memory: `cortex/PREFRONTAL.md`\n" +
 		"- Conversation summary: `cortex/CONTEXT.md`\n" +
 		"- Personality: `SOUL.md`\n\n" +
@@ -93,6 +122,7 @@ func defaultMinibrain() string {
 		"- Conversation summary is a compact rolling log of recent prompts and responses.\n\n" +
 		"## Promotion Guidance\n" +
 		"- Promote durable facts, preferences, or constraints to `NEO.md`.\n" +
+		"- Facts about a repository (its files, layout, tooling) go to the project NEO; personal preferences that hold everywhere go to the user NEO.\n" +
 		"- Keep `PREFRONTAL.md` focused on current session context and decisions.\n"
 }
 
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// withFileLock runs fn while holding an exclusive advisory lock for path.
+// The lock lives in a ".<name>.lock" file next to path, so it also excludes
+// other minibrain processes sharing the brain dir. Locks are not reentrant:
+// fn must not lock the same path again.
+func withFileLock(path string, fn func() error) error {
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	lockPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if err := lockFD(f); err != nil {
+		return err
+	}
+	defer func() { _ = unlockFD(f) }()
+	return fn()
+}
+
+// atomicWriteFile replaces path through a temp file and rename, so readers
+// never see a partly written file. Brain files are encrypted when the brain
+// dir has encryption on.
+func atomicWriteFile(path string, data []byte, perm os.FileMode) error {
+	return vault.WriteFile(path, data, perm)
+}
+
+// appendFile appends data to path. Encrypted files cannot be appended to in
+// place, so they are rewritten; callers hold the lock for path.
+func appendFile(path string, data []byte) error {
+	v, err := vault.For(path)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		old, err := readFileOrEmpty(path)
+		if err != nil {
+			return err
+		}
+		return atomicWriteFile(path, append([]byte(old), data...), 0600)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// appendLocked appends data to path under its lock.
+func appendLocked(path string, data []byte) error {
+	return withFileLock(path, func() error { return appendFile(path, data) })
+}
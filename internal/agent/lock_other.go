@@ -0,0 +1,24 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package agent
+
+import (
+	"os"
+	"sync"
+)
+
+// Platforms without flock or LockFileEx only get in-process locking.
+var fallbackLocks sync.Map
+
+func lockFD(f *os.File) error {
+	mu, _ := fallbackLocks.LoadOrStore(f.Name(), &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return nil
+}
+
+func unlockFD(f *os.File) error {
+	if mu, ok := fallbackLocks.Load(f.Name()); ok {
+		mu.(*sync.Mutex).Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func addFacts(neoPath, prefix string, n int) error {
+	for i := 0; i < n; i++ {
+		fact := fmt.Sprintf("%s item %d", prefix, i)
+		_, err := UpdateMemories(neoPath, ScopeUser, func(entries []MemoryEntry) ([]MemoryEntry, error) {
+			return append(entries, NewMemoryEntry(entries, fact, "test", ScopeUser, nil)), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkFacts(t *testing.T, neoPath string, workers, n int) {
+	t.Helper()
+	entries, err := LoadMemories(neoPath, ScopeUser)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(entries) != workers*n {
+		t.Fatalf("expected %d entries, got %d", workers*n, len(entries))
+	}
+	neo, _ := os.ReadFile(neoPath)
+	for w := 0; w < workers; w++ {
+		for i := 0; i < n; i++ {
+			fact := fmt.Sprintf("worker %d item %d", w, i)
+			if findMemory(entries, fact) < 0 || !strings.Contains(string(neo), "- "+fact+"\n") {
+				t.Fatalf("lost %q", fact)
+			}
+		}
+	}
+}
+
+func TestConcurrentMemoryUpdates(t *testing.T) {
+	neoPath := filepath.Join(t.TempDir(), "cortex", "NEO.md")
+	const workers, n = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs <- addFacts(neoPath, fmt.Sprintf("worker %d", w), n)
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+	checkFacts(t, neoPath, workers, n)
+}
+
+func TestConcurrentPrefrontalAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cortex", "PREFRONTAL.md")
+	if err := ClearShortTerm(Config{PrefrontalPath: path}); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	const workers, n = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				AppendPrefrontal(path, fmt.Sprintf("- note %d-%d\n", w, i))
+			}
+		}(w)
+	}
+	wg.Wait()
+	b, _ := os.ReadFile(path)
+	for w := 0; w < workers; w++ {
+		for i := 0; i < n; i++ {
+			if !strings.Contains(string(b), fmt.Sprintf("- note %d-%d\n", w, i)) {
+				t.Fatalf("lost note %d-%d", w, i)
+			}
+		}
+	}
+}
+
+// TestLockHelperProcess is run by TestConcurrentMemoryProcesses in child
+// processes; it does nothing in a normal test run.
+func TestLockHelperProcess(t *testing.T) {
+	neoPath := os.Getenv("MINIBRAIN_LOCK_HELPER_NEO")
+	if neoPath == "" {
+		t.Skip("helper process only")
+	}
+	n, _ := strconv.Atoi(os.Getenv("MINIBRAIN_LOCK_HELPER_N"))
+	if err := addFacts(neoPath, os.Getenv("MINIBRAIN_LOCK_HELPER_PREFIX"), n); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+}
+
+func TestConcurrentMemoryProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	neoPath := filepath.Join(t.TempDir(), "cortex", "NEO.md")
+	const workers, n = 4, 10
+	var cmds []*exec.Cmd
+	for w := 0; w < workers; w++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+		cmd.Env = append(os.Environ(),
+			"MINIBRAIN_LOCK_HELPER_NEO="+neoPath,
+			"MINIBRAIN_LOCK_HELPER_N="+strconv.Itoa(n),
+			fmt.Sprintf("MINIBRAIN_LOCK_HELPER_PREFIX=worker %d", w),
+		)
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("helper: %v", err)
+		}
+	}
+	checkFacts(t, neoPath, workers, n)
+}
+
+func TestAtomicWriteFileKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "NEO.md")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := atomicWriteFile(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected mode 0600 kept, got %v %v", info.Mode(), err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".NEO.md.tmp-*"))
+	if len(matches) != 0 {
+		t.Fatalf("temp files left behind: %v", matches)
+	}
+}
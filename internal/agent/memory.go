@@ -6,14 +6,47 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/chrishannah/minibrain/internal/vault"
 )
 
 func ensureDir(path string) error {
-	return os.MkdirAll(path, 0755)
+	return mkdirExcluded(path, 0755)
+}
+
+// ensurePrivateDir creates a directory under the brain dir, which only its
+// owner may read. Directories in the user's project use ensureDir.
+func ensurePrivateDir(path string) error {
+	return mkdirExcluded(path, 0700)
+}
+
+// mkdirExcluded creates path. When that creates a .minibrain dir inside a
+// git work tree, the dir is added to .git/info/exclude so checkpoints, the
+// audit log and the project config are not committed by accident.
+func mkdirExcluded(path string, perm os.FileMode) error {
+	dir := minibrainDir(path)
+	created := dir != "" && !fileExists(dir)
+	if err := os.MkdirAll(path, perm); err != nil {
+		return err
+	}
+	if created {
+		excludeFromGit(dir)
+	}
+	return nil
+}
+
+// minibrainDir returns the .minibrain dir path is in, if any.
+func minibrainDir(path string) string {
+	for p := filepath.Clean(path); filepath.Dir(p) != p; p = filepath.Dir(p) {
+		if filepath.Base(p) == ".minibrain" {
+			return p
+		}
+	}
+	return ""
 }
 
 func readFileOrEmpty(path string) (string, error) {
-	b, err := os.ReadFile(path)
+	b, err := vault.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return "", nil
@@ -52,22 +85,14 @@ func WritePrefrontalHeader(path, prompt string, mentions []string, refs []FileRe
 		}
 	}
 
-	if err := ensureDir(filepath.Dir(path)); err != nil {
-		return err
-	}
-
-	existing, _ := readFileOrEmpty(path)
-	if strings.TrimSpace(existing) != "" {
-		b.WriteString("\n---\n\n")
-	}
-
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
-	_, err = f.WriteString(b.String())
-	return err
+	return withFileLock(path, func() error {
+		existing, _ := readFileOrEmpty(path)
+		header := b.String()
+		if strings.TrimSpace(existing) != "" {
+			header += "\n---\n\n"
+		}
+		return appendFile(path, []byte(header))
+	})
 }
 
 func formatMentionPath(r FileRef) string {
@@ -78,10 +103,8 @@ func formatMentionPath(r FileRef) string {
 }
 
 func AppendPrefrontal(path, content string) {
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
+	if !fileExists(path) {
 		return
 	}
-	defer func() { _ = f.Close() }()
-	_, _ = f.WriteString(content)
+	_ = appendLocked(path, []byte(content))
 }
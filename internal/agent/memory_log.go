@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// MemoryChange records one change to long-term or short-term memory in
+// cortex/memory-log.jsonl so that it can be audited and reverted.
+type MemoryChange struct {
+	ID       string        `json:"id"`
+	Time     string        `json:"time"`
+	Action   string        `json:"action"`
+	Source   string        `json:"source,omitempty"`
+	Scope    MemoryScope   `json:"scope,omitempty"`
+	Path     string        `json:"path"`
+	Entries  []MemoryEntry `json:"entries,omitempty"`
+	Before   string        `json:"before,omitempty"`
+	After    string        `json:"after,omitempty"`
+	RevertOf string        `json:"revert_of,omitempty"`
+}
+
+const (
+	MemoryAdd    = "add"
+	MemoryForget = "forget"
+	MemoryEdit   = "edit"
+	MemoryRevert = "revert"
+)
+
+func MemoryLogPath(brainDir string) string {
+	return filepath.Join(brainDir, "cortex", "memory-log.jsonl")
+}
+
+func logMemoryChange(brainDir string, c MemoryChange) (MemoryChange, error) {
+	if brainDir == "" {
+		return c, nil
+	}
+	now := time.Now().UTC()
+	c.Time = now.Format(time.RFC3339)
+	c.ID = now.Format("20060102-150405") + "-" + newMemoryID()[:4]
+	b, err := json.Marshal(c)
+	if err != nil {
+		return c, err
+	}
+	return c, appendLocked(MemoryLogPath(brainDir), append(b, '\n'))
+}
+
+// ListMemoryChanges returns the change log, oldest first.
+func ListMemoryChanges(brainDir string) ([]MemoryChange, error) {
+	b, err := vault.ReadFile(MemoryLogPath(brainDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []MemoryChange
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var c MemoryChange
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, sc.Err()
+}
+
+// RevertMemoryChange undoes the change with id: added entries are forgotten,
+// forgotten entries come back, and edited files get their previous content.
+func RevertMemoryChange(cfg Config, id string) (MemoryChange, error) {
+	changes, err := ListMemoryChanges(cfg.BrainDir)
+	if err != nil {
+		return MemoryChange{}, err
+	}
+	var target *MemoryChange
+	for i := range changes {
+		if changes[i].RevertOf == id {
+			return MemoryChange{}, fmt.Errorf("change %s was already reverted by %s", id, changes[i].ID)
+		}
+		if changes[i].ID == id {
+			target = &changes[i]
+		}
+	}
+	if target == nil {
+		return MemoryChange{}, fmt.Errorf("memory change %s not found", id)
+	}
+
+	revert := MemoryChange{Action: MemoryRevert, Source: "revert", Scope: target.Scope, Path: target.Path, RevertOf: id}
+	switch target.Action {
+	case MemoryAdd:
+		_, err = UpdateMemories(target.Path, target.Scope, func(entries []MemoryEntry) ([]MemoryEntry, error) {
+			drop := map[string]bool{}
+			for _, e := range target.Entries {
+				drop[e.ID] = true
+			}
+			kept := entries[:0]
+			for _, e := range entries {
+				if drop[e.ID] {
+					revert.Entries = append(revert.Entries, e)
+					continue
+				}
+				kept = append(kept, e)
+			}
+			return kept, nil
+		})
+	case MemoryForget:
+		_, err = UpdateMemories(target.Path, target.Scope, func(entries []MemoryEntry) ([]MemoryEntry, error) {
+			for _, e := range target.Entries {
+				if findMemory(entries, e.Fact) >= 0 {
+					continue
+				}
+				for _, other := range entries {
+					if other.ID == e.ID {
+						e.ID = NewMemoryEntry(entries, e.Fact, "", "", nil).ID
+						break
+					}
+				}
+				entries = append(entries, e)
+				revert.Entries = append(revert.Entries, e)
+			}
+			return entries, nil
+		})
+	case MemoryEdit:
+		err = withFileLock(target.Path, func() error {
+			current, err := readFileOrEmpty(target.Path)
+			if err != nil {
+				return err
+			}
+			if current != target.After {
+				return fmt.Errorf("%s changed after %s; edit it by hand instead", filepath.Base(target.Path), id)
+			}
+			revert.Before, revert.After = current, target.Before
+			if err := writeBrainFileLocked(target.Path, target.Before, "revert "+id); err != nil {
+				return err
+			}
+			if target.Scope != "" {
+				_, err = loadMemoriesLocked(target.Path, target.Scope)
+			}
+			return err
+		})
+	default:
+		err = fmt.Errorf("cannot revert a %s change", target.Action)
+	}
+	if err != nil {
+		return MemoryChange{}, err
+	}
+	return logMemoryChange(cfg.BrainDir, revert)
+}
+
+func FormatMemoryChange(c MemoryChange) string {
+	var detail []string
+	for _, e := range c.Entries {
+		detail = append(detail, e.ID+" "+e.Fact)
+	}
+	if len(detail) == 0 {
+		detail = append(detail, filepath.Base(c.Path))
+	}
+	line := c.ID + "  " + c.Action
+	if c.Scope != "" {
+		line += " [" + string(c.Scope) + "]"
+	}
+	line += "  " + strings.Join(detail, "; ")
+	if c.RevertOf != "" {
+		line += " (reverts " + c.RevertOf + ")"
+	}
+	if c.Source != "" {
+		line += " via " + c.Source
+	}
+	return line
+}
@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxMemoryFactLen = 500
+
+// AllMemories returns the user entries followed by the project entries.
+func AllMemories(cfg Config) ([]MemoryEntry, error) {
+	user, err := LoadMemories(resolveNeoPath(cfg), ScopeUser)
+	if err != nil {
+		return nil, err
+	}
+	var project []MemoryEntry
+	if path := resolveProjectNeoPath(cfg); path != "" {
+		if project, err = LoadMemories(path, ScopeProject); err != nil {
+			return nil, err
+		}
+	}
+	return append(user, project...), nil
+}
+
+func memoryPath(cfg Config, scope MemoryScope) string {
+	if scope == ScopeUser {
+		return resolveNeoPath(cfg)
+	}
+	return resolveProjectNeoPath(cfg)
+}
+
+// RememberFact adds text as a new entry. Words starting with # become tags,
+// and a leading "user:" or "project:" picks the scope instead of the
+// classifier.
+func RememberFact(cfg Config, text string) (MemoryEntry, error) {
+	var scope MemoryScope
+	lower := strings.ToLower(strings.TrimSpace(text))
+	for _, s := range []MemoryScope{ScopeUser, ScopeProject} {
+		if strings.HasPrefix(lower, string(s)+":") {
+			scope = s
+			text = strings.TrimSpace(text)[len(s)+1:]
+			break
+		}
+	}
+	var words, tags []string
+	for _, w := range strings.Fields(text) {
+		if len(w) > 1 && strings.HasPrefix(w, "#") {
+			tags = append(tags, strings.ToLower(strings.TrimPrefix(w, "#")))
+			continue
+		}
+		words = append(words, w)
+	}
+	fact := normalizeFact(redactText(cfg, cfg.BrainDir, "memory", strings.Join(words, " ")))
+	if err := validateFact(fact); err != nil {
+		return MemoryEntry{}, err
+	}
+	all, err := AllMemories(cfg)
+	if err != nil {
+		return MemoryEntry{}, err
+	}
+	if i := findMemory(all, fact); i >= 0 {
+		return MemoryEntry{}, fmt.Errorf("already remembered as %s", all[i].ID)
+	}
+	if scope == "" {
+		scope = ClassifyMemoryScope(cfg.RootDir, fact)
+	}
+	path := memoryPath(cfg, scope)
+	var entry MemoryEntry
+	_, err = UpdateMemories(path, scope, func(entries []MemoryEntry) ([]MemoryEntry, error) {
+		entry = NewMemoryEntry(entries, fact, "remember", scope, tags)
+		return append(entries, entry), nil
+	})
+	if err != nil {
+		return MemoryEntry{}, err
+	}
+	_, err = logMemoryChange(cfg.BrainDir, MemoryChange{Action: MemoryAdd, Source: "remember", Scope: scope, Path: path, Entries: []MemoryEntry{entry}})
+	return entry, err
+}
+
+// FindMemories lists every entry when query is empty, and otherwise the
+// entries matching it, best first.
+func FindMemories(cfg Config, query string) ([]MemoryEntry, error) {
+	all, err := AllMemories(cfg)
+	if err != nil || strings.TrimSpace(query) == "" {
+		return all, err
+	}
+	return SearchMemories(all, query), nil
+}
+
+// ForgetMemory removes the entry with ID arg, or the single entry matching
+// arg as a query. Ambiguous queries are refused.
+func ForgetMemory(cfg Config, arg string) (MemoryEntry, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return MemoryEntry{}, errors.New("usage: forget <id|query>")
+	}
+	all, err := AllMemories(cfg)
+	if err != nil {
+		return MemoryEntry{}, err
+	}
+	var target *MemoryEntry
+	for i := range all {
+		if all[i].ID == arg {
+			target = &all[i]
+			break
+		}
+	}
+	if target == nil {
+		matches := SearchMemories(all, arg)
+		switch len(matches) {
+		case 0:
+			return MemoryEntry{}, fmt.Errorf("no memory matches %q", arg)
+		case 1:
+			target = &matches[0]
+		default:
+			var ids []string
+			for _, m := range matches {
+				ids = append(ids, m.ID)
+			}
+			return MemoryEntry{}, fmt.Errorf("%d memories match %q; forget one by id: %s", len(matches), arg, strings.Join(ids, ", "))
+		}
+	}
+	forgotten := *target
+	path := memoryPath(cfg, forgotten.Scope)
+	_, err = UpdateMemories(path, forgotten.Scope, func(entries []MemoryEntry) ([]MemoryEntry, error) {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.ID != forgotten.ID {
+				kept = append(kept, e)
+			}
+		}
+		return kept, nil
+	})
+	if err != nil {
+		return MemoryEntry{}, err
+	}
+	_, err = logMemoryChange(cfg.BrainDir, MemoryChange{Action: MemoryForget, Source: "forget", Scope: forgotten.Scope, Path: path, Entries: []MemoryEntry{forgotten}})
+	return forgotten, err
+}
+
+// MemoryEditTarget resolves the file opened by "/memory edit [target]":
+// neo (user NEO, the default), project (project NEO) or prefrontal.
+func MemoryEditTarget(cfg Config, target string) (string, MemoryScope, error) {
+	switch strings.ToLower(strings.TrimSpace(target)) {
+	case "", "neo", "user":
+		return resolveNeoPath(cfg), ScopeUser, nil
+	case "project":
+		path := resolveProjectNeoPath(cfg)
+		if path == "" {
+			return "", "", errors.New("no project root")
+		}
+		return path, ScopeProject, nil
+	case "prefrontal", "stm":
+		return resolvePrefrontalPath(cfg), "", nil
+	}
+	return "", "", fmt.Errorf("unknown memory file %q (use neo, project or prefrontal)", target)
+}
+
+// ValidateMemoryFile checks edited content before it replaces a memory file.
+// NEO files (scope set) must hold one fact per bullet without duplicates.
+func ValidateMemoryFile(scope MemoryScope, content string) error {
+	if !utf8.ValidString(content) {
+		return errors.New("content is not valid UTF-8")
+	}
+	if strings.ContainsRune(content, 0) {
+		return errors.New("content contains NUL bytes")
+	}
+	if scope == "" {
+		return nil
+	}
+	seen := map[string]int{}
+	for i, line := range strings.Split(content, "\n") {
+		if !isNeoBullet(line) {
+			continue
+		}
+		fact := normalizeFact(line)
+		if err := validateFact(fact); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		key := strings.ToLower(fact)
+		if prev, ok := seen[key]; ok {
+			return fmt.Errorf("line %d: duplicate of line %d", i+1, prev)
+		}
+		seen[key] = i + 1
+	}
+	return nil
+}
+
+func validateFact(fact string) error {
+	if fact == "" {
+		return errors.New("fact is empty")
+	}
+	if len(fact) > maxMemoryFactLen {
+		return fmt.Errorf("fact is longer than %d bytes", maxMemoryFactLen)
+	}
+	return nil
+}
+
+// SaveMemoryFile validates content and writes it to path, re-syncing the
+// entry store for NEO files and recording the edit.
+func SaveMemoryFile(cfg Config, path string, scope MemoryScope, content string) (MemoryChange, error) {
+	if err := ValidateMemoryFile(scope, content); err != nil {
+		return MemoryChange{}, err
+	}
+	content = redactText(cfg, cfg.BrainDir, "edit "+filepath.Base(path), content)
+	var before string
+	err := withFileLock(path, func() error {
+		var err error
+		if before, err = readFileOrEmpty(path); err != nil || before == content {
+			return err
+		}
+		if err := writeBrainFileLocked(path, content, "edit"); err != nil {
+			return err
+		}
+		if scope != "" {
+			_, err = loadMemoriesLocked(path, scope)
+		}
+		return err
+	})
+	if err != nil || before == content {
+		return MemoryChange{}, err
+	}
+	return logMemoryChange(cfg.BrainDir, MemoryChange{Action: MemoryEdit, Source: "editor", Scope: scope, Path: path, Before: before, After: content})
+}
+
+func FormatMemoryEntry(e MemoryEntry) string {
+	line := e.ID + "  [" + string(e.Scope) + "] " + e.Fact
+	for _, t := range e.Tags {
+		line += " #" + t
+	}
+	if e.LastUsed != "" {
+		line += "  (used " + e.LastUsed + ")"
+	}
+	return line
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRememberForgetAndRevert(t *testing.T) {
+	cfg := Config{RootDir: t.TempDir(), BrainDir: t.TempDir()}
+
+	e, err := RememberFact(cfg, "user: Prefers short commit subjects #git")
+	if err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	if e.Scope != ScopeUser || e.Fact != "Prefers short commit subjects" || len(e.Tags) != 1 || e.Tags[0] != "git" {
+		t.Fatalf("unexpected entry: %#v", e)
+	}
+	if _, err := RememberFact(cfg, "prefers short commit  subjects"); err == nil || !strings.Contains(err.Error(), e.ID) {
+		t.Fatalf("expected duplicate error naming %s, got %v", e.ID, err)
+	}
+	if _, err := RememberFact(cfg, "The repo builds with make"); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	if _, err := RememberFact(cfg, "Make targets live in the repo root"); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+
+	if found, _ := FindMemories(cfg, "commit"); len(found) != 1 || found[0].ID != e.ID {
+		t.Fatalf("unexpected search result: %#v", found)
+	}
+	if _, err := ForgetMemory(cfg, "make"); err == nil || !strings.Contains(err.Error(), "2 memories match") {
+		t.Fatalf("expected ambiguous forget, got %v", err)
+	}
+	if _, err := ForgetMemory(cfg, e.ID); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	if neo, _ := os.ReadFile(resolveNeoPath(cfg)); strings.Contains(string(neo), "commit subjects") {
+		t.Fatalf("expected fact removed from NEO, got %q", string(neo))
+	}
+
+	changes, err := ListMemoryChanges(cfg.BrainDir)
+	if err != nil || len(changes) != 4 || changes[3].Action != MemoryForget {
+		t.Fatalf("unexpected change log: %#v (%v)", changes, err)
+	}
+	if _, err := RevertMemoryChange(cfg, changes[3].ID); err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	all, _ := AllMemories(cfg)
+	if len(all) != 3 || all[0].ID != e.ID || all[0].Tags[0] != "git" {
+		t.Fatalf("expected forgotten entry restored, got %#v", all)
+	}
+	if _, err := RevertMemoryChange(cfg, changes[3].ID); err == nil {
+		t.Fatal("expected second revert to fail")
+	}
+	if _, err := RevertMemoryChange(cfg, changes[1].ID); err != nil {
+		t.Fatalf("revert add: %v", err)
+	}
+	if all, _ := AllMemories(cfg); len(all) != 2 {
+		t.Fatalf("expected added entry removed, got %#v", all)
+	}
+}
+
+func TestSaveMemoryFileValidatesAndReverts(t *testing.T) {
+	cfg := Config{RootDir: t.TempDir(), BrainDir: t.TempDir()}
+	path, scope, err := MemoryEditTarget(cfg, "project")
+	if err != nil || scope != ScopeProject {
+		t.Fatalf("edit target: %v", err)
+	}
+	if _, err := SaveMemoryFile(cfg, path, scope, "# NEO\n\n- One\n- one\n"); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("expected duplicate error, got %v", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Fatal("invalid edit should not be written")
+	}
+	c, err := SaveMemoryFile(cfg, path, scope, "# NEO\n\n- One\n- Two\n")
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if entries, _ := LoadMemories(path, ScopeProject); len(entries) != 2 {
+		t.Fatalf("expected store synced with edit, got %#v", entries)
+	}
+	if _, err := RevertMemoryChange(cfg, c.ID); err != nil {
+		t.Fatalf("revert edit: %v", err)
+	}
+	if b, _ := os.ReadFile(path); len(b) != 0 {
+		t.Fatalf("expected empty file after revert, got %q", string(b))
+	}
+	if entries, _ := LoadMemories(path, ScopeProject); len(entries) != 0 {
+		t.Fatalf("expected store emptied after revert, got %#v", entries)
+	}
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const defaultMemoryTopK = 12
+
+var rankStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "do": true, "for": true, "from": true, "in": true,
+	"is": true, "it": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "this": true, "to": true, "use": true, "uses": true,
+	"was": true, "we": true, "with": true, "you": true, "i": true, "me": true,
+	"my": true, "please": true, "can": true, "should": true, "when": true,
+}
+
+// RankMemories returns up to k entries relevant to query, best first, using
+// BM25 over each entry's fact and tags. Entries tagged "always" are always
+// included, and when there are no more than k entries all of them are used.
+func RankMemories(entries []MemoryEntry, query string, k int) []MemoryEntry {
+	if k <= 0 {
+		k = defaultMemoryTopK
+	}
+	if len(entries) <= k {
+		return entries
+	}
+	var out []MemoryEntry
+	for _, e := range entries {
+		if hasTag(e, "always") {
+			out = append(out, e)
+		}
+	}
+	for _, e := range SearchMemories(entries, query) {
+		if len(out) >= k {
+			break
+		}
+		if !hasTag(e, "always") {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// SearchMemories returns the entries matching query, best first.
+func SearchMemories(entries []MemoryEntry, query string) []MemoryEntry {
+	scores := bm25Scores(entries, query)
+	var idx []int
+	for i, s := range scores {
+		if s > 0 {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return scores[idx[i]] > scores[idx[j]] })
+	out := make([]MemoryEntry, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, entries[i])
+	}
+	return out
+}
+
+func bm25Scores(entries []MemoryEntry, query string) []float64 {
+	scores := make([]float64, len(entries))
+	if len(entries) == 0 {
+		return scores
+	}
+	docs := make([][]string, len(entries))
+	var totalLen int
+	df := map[string]int{}
+	for i, e := range entries {
+		docs[i] = rankTokens(e.Fact + " " + strings.Join(e.Tags, " "))
+		totalLen += len(docs[i])
+		seen := map[string]bool{}
+		for _, t := range docs[i] {
+			if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+	}
+	avgLen := float64(totalLen) / float64(len(entries))
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	const k1, b = 1.2, 0.75
+	n := float64(len(entries))
+	terms := uniqueStrings(rankTokens(query))
+	for i, doc := range docs {
+		tf := map[string]int{}
+		for _, t := range doc {
+			tf[t]++
+		}
+		for _, t := range terms {
+			f := float64(tf[t])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			scores[i] += idf * f * (k1 + 1) / (f + k1*(1-b+b*float64(len(doc))/avgLen))
+		}
+	}
+	return scores
+}
+
+func rankTokens(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if len(f) < 2 || rankStopwords[f] {
+			continue
+		}
+		out = append(out, stemToken(f))
+	}
+	return out
+}
+
+// stemToken strips a few common English suffixes so that "tests" matches
+// "test" and "deploying" matches "deploy".
+func stemToken(t string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if len(t) > len(suffix)+2 && strings.HasSuffix(t, suffix) {
+			return strings.TrimSuffix(t, suffix)
+		}
+	}
+	return t
+}
+
+func hasTag(e MemoryEntry, tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(in []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
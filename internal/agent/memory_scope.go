@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// MemoryScope says which long-term memory a fact belongs to: the user-global
+// cortex/NEO.md or the project's .minibrain/NEO.md.
+type MemoryScope string
+
+const (
+	ScopeUser    MemoryScope = "user"
+	ScopeProject MemoryScope = "project"
+)
+
+func ProjectNeoPath(root string) string {
+	return filepath.Join(root, ".minibrain", "NEO.md")
+}
+
+func resolveNeoPath(cfg Config) string {
+	if cfg.NeoPath != "" {
+		return cfg.NeoPath
+	}
+	return filepath.Join(cfg.BrainDir, "cortex", "NEO.md")
+}
+
+func resolveProjectNeoPath(cfg Config) string {
+	if cfg.ProjectNeoPath != "" {
+		return cfg.ProjectNeoPath
+	}
+	if cfg.RootDir == "" {
+		return ""
+	}
+	return ProjectNeoPath(cfg.RootDir)
+}
+
+var projectWords = map[string]bool{
+	"repo": true, "repository": true, "codebase": true, "project": true,
+	"package": true, "module": true, "directory": true, "folder": true,
+	"file": true, "files": true, "function": true, "endpoint": true,
+	"schema": true, "migration": true, "branch": true, "ci": true,
+	"build": true, "tests": true, "dependency": true, "dependencies": true,
+}
+
+var userPhrases = []string{
+	"i prefer", "i like", "i use", "i want", "i always", "i never",
+	"user prefers", "user likes", "user wants", "user uses",
+	"my ", "in general", "all projects", "every project", "globally",
+}
+
+// ClassifyMemoryScope decides where a fact should be promoted. Anything that
+// refers to the repository (its files, its name, or project vocabulary) is
+// project-scoped. Personal preferences with no project reference are
+// user-scoped. When unsure it picks the project, so that facts do not leak
+// into unrelated repositories.
+func ClassifyMemoryScope(root, fact string) MemoryScope {
+	lower := strings.ToLower(fact)
+	name := strings.ToLower(filepath.Base(root))
+	for _, tok := range strings.Fields(fact) {
+		tok = strings.Trim(tok, "`'\"()[]{},;:!?")
+		word := strings.ToLower(strings.TrimRight(tok, "."))
+		if projectWords[word] || (len(name) >= 3 && word == name) {
+			return ScopeProject
+		}
+		if root != "" && (strings.Contains(tok, "/") || strings.Contains(strings.TrimRight(tok, "."), ".")) {
+			if clean, err := safeRelPath(strings.TrimRight(tok, ".")); err == nil && fileExists(filepath.Join(root, clean)) {
+				return ScopeProject
+			}
+		}
+	}
+	for _, p := range userPhrases {
+		if strings.Contains(lower, p) {
+			return ScopeUser
+		}
+	}
+	return ScopeProject
+}
+
+// PromoteFact appends fact to the long-term memory for scope, classifying it
+// first when scope is empty. It reports false when the fact is already there.
+func PromoteFact(cfg Config, fact string, scope MemoryScope) (MemoryScope, bool, error) {
+	fact = normalizeFact(fact)
+	if fact == "" {
+		return "", false, errors.New("fact is empty")
+	}
+	if scope == "" {
+		scope = ClassifyMemoryScope(cfg.RootDir, fact)
+	}
+	var path string
+	switch scope {
+	case ScopeUser:
+		path = resolveNeoPath(cfg)
+	case ScopeProject:
+		path = resolveProjectNeoPath(cfg)
+	default:
+		return "", false, errors.New("unknown memory scope: " + string(scope))
+	}
+	if path == "" {
+		return scope, false, errors.New("no path for " + string(scope) + " memory")
+	}
+	var entry *MemoryEntry
+	_, err := UpdateMemories(path, scope, func(entries []MemoryEntry) ([]MemoryEntry, error) {
+		if findMemory(entries, fact) >= 0 {
+			return entries, nil
+		}
+		e := NewMemoryEntry(entries, fact, "promotion", scope, nil)
+		entry = &e
+		return append(entries, e), nil
+	})
+	if err != nil || entry == nil {
+		return scope, false, err
+	}
+	_, err = logMemoryChange(cfg.BrainDir, MemoryChange{Action: MemoryAdd, Source: "promotion", Scope: scope, Path: path, Entries: []MemoryEntry{*entry}})
+	return scope, true, err
+}
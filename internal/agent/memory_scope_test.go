@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyMemoryScope(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "webshop")
+	if err := os.MkdirAll(filepath.Join(root, "internal"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "internal", "db.go"), []byte("package internal\n"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	cases := map[string]MemoryScope{
+		"User prefers concise answers":                 ScopeUser,
+		"I prefer tabs over spaces":                    ScopeUser,
+		"The repo uses sqlc for queries":               ScopeProject,
+		"Connection pooling lives in internal/db.go.":  ScopeProject,
+		"webshop deploys on Fridays":                   ScopeProject,
+		"I prefer table-driven tests in this codebase": ScopeProject,
+		"Deploys happen via the release script":        ScopeProject,
+	}
+	for fact, want := range cases {
+		if got := ClassifyMemoryScope(root, fact); got != want {
+			t.Errorf("%q: expected %s, got %s", fact, want, got)
+		}
+	}
+}
+
+func TestPromoteFact(t *testing.T) {
+	root := t.TempDir()
+	brain := t.TempDir()
+	cfg := Config{RootDir: root, BrainDir: brain}
+
+	scope, added, err := PromoteFact(cfg, "User prefers concise answers", "")
+	if err != nil || scope != ScopeUser || !added {
+		t.Fatalf("expected user promotion, got %s %v %v", scope, added, err)
+	}
+	scope, added, err = PromoteFact(cfg, "- The repo uses sqlc", "")
+	if err != nil || scope != ScopeProject || !added {
+		t.Fatalf("expected project promotion, got %s %v %v", scope, added, err)
+	}
+	if _, added, _ = PromoteFact(cfg, "the repo uses  sqlc", ScopeProject); added {
+		t.Fatal("expected duplicate fact to be skipped")
+	}
+
+	b, err := os.ReadFile(ProjectNeoPath(root))
+	if err != nil || !strings.Contains(string(b), "- The repo uses sqlc\n") {
+		t.Fatalf("unexpected project NEO: %q (%v)", string(b), err)
+	}
+	b, _ = os.ReadFile(filepath.Join(brain, "cortex", "NEO.md"))
+	if strings.Contains(string(b), "sqlc") || !strings.Contains(string(b), "concise") {
+		t.Fatalf("unexpected user NEO: %q", string(b))
+	}
+}
@@ -0,0 +1,336 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// MemoryEntry is one long-term memory. Entries live in memories.jsonl next to
+// the NEO.md they belong to; NEO.md is kept as a human-editable export.
+type MemoryEntry struct {
+	ID       string      `json:"id"`
+	Fact     string      `json:"fact"`
+	Tags     []string    `json:"tags,omitempty"`
+	Source   string      `json:"source,omitempty"`
+	Scope    MemoryScope `json:"scope,omitempty"`
+	Created  string      `json:"created"`
+	LastUsed string      `json:"last_used,omitempty"`
+}
+
+func MemoryStorePath(neoPath string) string {
+	return filepath.Join(filepath.Dir(neoPath), "memories.jsonl")
+}
+
+// LoadMemories returns the entries for the NEO file at neoPath. Bullets added
+// to NEO.md by hand are imported and entries whose bullet was deleted are
+// dropped, so NEO.md stays the place to edit memory directly.
+func LoadMemories(neoPath string, scope MemoryScope) ([]MemoryEntry, error) {
+	var entries []MemoryEntry
+	err := withFileLock(neoPath, func() error {
+		var err error
+		entries, err = loadMemoriesLocked(neoPath, scope)
+		return err
+	})
+	return entries, err
+}
+
+// loadMemoriesLocked is LoadMemories for callers holding the neoPath lock,
+// which guards both NEO.md and its store.
+func loadMemoriesLocked(neoPath string, scope MemoryScope) ([]MemoryEntry, error) {
+	storePath := MemoryStorePath(neoPath)
+	entries, err := readMemoryStore(storePath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(neoPath) {
+		if len(entries) > 0 {
+			if err := exportNeo(neoPath, scope, entries); err != nil {
+				return nil, err
+			}
+		}
+		return entries, nil
+	}
+	neo, err := readFileOrEmpty(neoPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].Scope == "" {
+			entries[i].Scope = scope
+		}
+	}
+	reconciled, changed := reconcileMemories(entries, neoBullets(neo), scope)
+	if changed {
+		if err := writeMemoryStore(storePath, reconciled); err != nil {
+			return nil, err
+		}
+	}
+	return reconciled, nil
+}
+
+// UpdateMemories loads the entries for neoPath, lets fn change them, and
+// saves both the store and the NEO.md export.
+func UpdateMemories(neoPath string, scope MemoryScope, fn func([]MemoryEntry) ([]MemoryEntry, error)) ([]MemoryEntry, error) {
+	var entries []MemoryEntry
+	err := withFileLock(neoPath, func() error {
+		loaded, err := loadMemoriesLocked(neoPath, scope)
+		if err != nil {
+			return err
+		}
+		if entries, err = fn(loaded); err != nil {
+			return err
+		}
+		if err := writeMemoryStore(MemoryStorePath(neoPath), entries); err != nil {
+			return err
+		}
+		return exportNeo(neoPath, scope, entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// NewMemoryEntry builds an entry with a fresh ID that does not clash with
+// existing.
+func NewMemoryEntry(existing []MemoryEntry, fact, source string, scope MemoryScope, tags []string) MemoryEntry {
+	used := map[string]bool{}
+	for _, e := range existing {
+		used[e.ID] = true
+	}
+	id := newMemoryID()
+	for used[id] {
+		id = newMemoryID()
+	}
+	return MemoryEntry{
+		ID:      id,
+		Fact:    normalizeFact(fact),
+		Tags:    tags,
+		Source:  source,
+		Scope:   scope,
+		Created: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func newMemoryID() string {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func findMemory(entries []MemoryEntry, fact string) int {
+	for i, e := range entries {
+		if strings.EqualFold(e.Fact, fact) {
+			return i
+		}
+	}
+	return -1
+}
+
+func reconcileMemories(entries []MemoryEntry, facts []string, scope MemoryScope) ([]MemoryEntry, bool) {
+	inNeo := map[string]bool{}
+	for _, f := range facts {
+		inNeo[strings.ToLower(f)] = true
+	}
+	changed := false
+	var out []MemoryEntry
+	seen := map[string]bool{}
+	for _, e := range entries {
+		key := strings.ToLower(e.Fact)
+		if !inNeo[key] || seen[key] {
+			changed = true
+			continue
+		}
+		seen[key] = true
+		out = append(out, e)
+	}
+	for _, f := range facts {
+		key := strings.ToLower(f)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, NewMemoryEntry(out, f, "NEO.md", scope, nil))
+		changed = true
+	}
+	return out, changed
+}
+
+func neoBullets(content string) []string {
+	var out []string
+	for _, line := range strings.Split(content, "\n") {
+		if !isNeoBullet(line) {
+			continue
+		}
+		if fact := normalizeFact(line); fact != "" {
+			out = append(out, fact)
+		}
+	}
+	return out
+}
+
+func isNeoBullet(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")
+}
+
+// exportNeo rewrites NEO.md from entries, keeping headings and prose in place
+// and only touching bullet lines. Callers hold the neoPath lock.
+func exportNeo(neoPath string, scope MemoryScope, entries []MemoryEntry) error {
+	old, err := readFileOrEmpty(neoPath)
+	if err != nil {
+		return err
+	}
+	want := map[string]bool{}
+	for _, e := range entries {
+		want[strings.ToLower(e.Fact)] = true
+	}
+	written := map[string]bool{}
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(old, "\n") {
+		if line == "" {
+			continue
+		}
+		if isNeoBullet(line) {
+			key := strings.ToLower(normalizeFact(line))
+			if !want[key] || written[key] {
+				continue
+			}
+			written[key] = true
+		}
+		b.WriteString(line)
+	}
+	content := b.String()
+	for _, e := range entries {
+		if written[strings.ToLower(e.Fact)] {
+			continue
+		}
+		if content == "" {
+			content = neoHeader(scope)
+		} else if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += "- " + e.Fact + "\n"
+		written[strings.ToLower(e.Fact)] = true
+	}
+	if content == old {
+		return nil
+	}
+	return writeBrainFileLocked(neoPath, content, "memory")
+}
+
+func readMemoryStore(path string) ([]MemoryEntry, error) {
+	b, err := vault.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []MemoryEntry
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var e MemoryEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filepath.Base(path), n, err)
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+func writeMemoryStore(path string, entries []MemoryEntry) error {
+	var b bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	if err := ensurePrivateDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return atomicWriteFile(path, b.Bytes(), 0600)
+}
+
+// recallMemories picks the entries relevant to prompt from both scopes,
+// records that they were used, and formats them for the developer message.
+func recallMemories(neoPath, projectNeoPath, prompt string, k int) (string, string, error) {
+	user, err := recallScope(neoPath, ScopeUser, prompt, k)
+	if err != nil {
+		return "", "", err
+	}
+	project, err := recallScope(projectNeoPath, ScopeProject, prompt, k)
+	if err != nil {
+		return "", "", err
+	}
+	return user, project, nil
+}
+
+// recallScope records LastUsed at most once a day per entry, so most turns
+// only read the store. A scope with no memory at all is not touched.
+func recallScope(neoPath string, scope MemoryScope, prompt string, k int) (string, error) {
+	if neoPath == "" || (!fileExists(neoPath) && !fileExists(MemoryStorePath(neoPath))) {
+		return "", nil
+	}
+	var entries, picked []MemoryEntry
+	err := withFileLock(neoPath, func() error {
+		var err error
+		entries, err = loadMemoriesLocked(neoPath, scope)
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+		picked = RankMemories(entries, prompt, k)
+		if len(picked) == 0 {
+			return nil
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		today := now[:len("2006-01-02")]
+		used := map[string]bool{}
+		for _, e := range picked {
+			used[e.ID] = true
+		}
+		changed := false
+		for i := range entries {
+			if used[entries[i].ID] && !strings.HasPrefix(entries[i].LastUsed, today) {
+				entries[i].LastUsed = now
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		return writeMemoryStore(MemoryStorePath(neoPath), entries)
+	})
+	if err != nil || len(picked) == 0 {
+		return "", err
+	}
+	var b strings.Builder
+	for _, e := range picked {
+		b.WriteString("- " + e.Fact + "\n")
+	}
+	if len(picked) < len(entries) {
+		fmt.Fprintf(&b, "(%d of %d entries, selected for relevance)\n", len(picked), len(entries))
+	}
+	return b.String(), nil
+}
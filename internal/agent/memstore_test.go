@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+func TestLoadMemoriesReconcilesWithNeo(t *testing.T) {
+	dir := t.TempDir()
+	neoPath := filepath.Join(dir, "NEO.md")
+	if err := os.WriteFile(neoPath, []byte("# Long-Term Memory (NEO)\n\nNotes kept by hand.\n\n- Uses Go 1.24\n- Deploys on Fridays\n"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	entries, err := LoadMemories(neoPath, ScopeProject)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected 2 imported entries, got %d (%v)", len(entries), err)
+	}
+	if entries[0].ID == "" || entries[0].Source != "NEO.md" || entries[0].Scope != ScopeProject {
+		t.Fatalf("unexpected entry: %#v", entries[0])
+	}
+	id := entries[0].ID
+
+	// Hand edit: drop one bullet, add another.
+	if err := os.WriteFile(neoPath, []byte("# Long-Term Memory (NEO)\n\nNotes kept by hand.\n\n- Uses Go 1.24\n- CI runs on GitHub Actions\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	entries, err = LoadMemories(neoPath, ScopeProject)
+	if err != nil || len(entries) != 2 || entries[0].ID != id || entries[1].Fact != "CI runs on GitHub Actions" {
+		t.Fatalf("unexpected reconcile result: %#v (%v)", entries, err)
+	}
+
+	_, err = UpdateMemories(neoPath, ScopeProject, func(es []MemoryEntry) ([]MemoryEntry, error) {
+		return append(es[1:], NewMemoryEntry(es, "Release tags are signed", "test", ScopeProject, []string{"release"})), nil
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	b, _ := os.ReadFile(neoPath)
+	want := "# Long-Term Memory (NEO)\n\nNotes kept by hand.\n\n- CI runs on GitHub Actions\n- Release tags are signed\n"
+	if string(b) != want {
+		t.Fatalf("unexpected export:\n%q\nwant\n%q", string(b), want)
+	}
+	store, _ := os.ReadFile(MemoryStorePath(neoPath))
+	if strings.Count(string(store), "\n") != 2 || !strings.Contains(string(store), `"tags":["release"]`) {
+		t.Fatalf("unexpected store: %s", store)
+	}
+}
+
+func TestRankMemories(t *testing.T) {
+	facts := []string{
+		"The API server lives in cmd/server",
+		"Database migrations are written with goose",
+		"User prefers concise answers",
+		"Frontend is built with Vite",
+		"Tests need a running Postgres",
+	}
+	var entries []MemoryEntry
+	for _, f := range facts {
+		entries = append(entries, NewMemoryEntry(entries, f, "test", ScopeProject, nil))
+	}
+	entries[2].Tags = []string{"always"}
+
+	got := RankMemories(entries, "add a migration for the users table", 2)
+	if len(got) != 2 || got[0].Fact != facts[2] || got[1].Fact != facts[1] {
+		t.Fatalf("unexpected ranking: %#v", got)
+	}
+	got = RankMemories(entries, "why are the tests failing against postgres?", 3)
+	if len(got) != 2 || got[1].Fact != facts[4] {
+		t.Fatalf("unexpected ranking: %#v", got)
+	}
+	if got := RankMemories(entries, "anything", 10); len(got) != len(entries) {
+		t.Fatalf("expected all entries when under k, got %d", len(got))
+	}
+}
+
+func TestEncryptedBrainDir(t *testing.T) {
+	brainDir := t.TempDir()
+	cfg := Config{RootDir: t.TempDir(), BrainDir: brainDir}
+	if err := EnsureBrainLayout(brainDir, ""); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(vault.PassphraseEnv, "")
+	t.Setenv(vault.KeyEnv, strings.Repeat("0f", 32))
+	if _, err := vault.Create(brainDir); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if _, err := RememberFact(cfg, "user: Prefers tabs over spaces"); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	AppendPrefrontal(resolvePrefrontalPath(cfg), "- note\n")
+	for _, path := range []string{resolveNeoPath(cfg), MemoryStorePath(resolveNeoPath(cfg)), MemoryLogPath(brainDir)} {
+		raw, err := os.ReadFile(path)
+		if err != nil || !vault.IsEncrypted(raw) {
+			t.Fatalf("expected %s encrypted (%v)", filepath.Base(path), err)
+		}
+	}
+	entries, err := LoadMemories(resolveNeoPath(cfg), ScopeUser)
+	if err != nil || findMemory(entries, "Prefers tabs over spaces") < 0 {
+		t.Fatalf("load: %#v %v", entries, err)
+	}
+	if stm, _ := readFileOrEmpty(resolvePrefrontalPath(cfg)); !strings.HasSuffix(stm, "- note\n") {
+		t.Fatalf("expected appended note, got %q", stm)
+	}
+	if info, _ := os.Stat(brainDir); info.Mode().Perm() != 0700 {
+		t.Fatalf("expected brain dir 0700, got %v", info.Mode())
+	}
+}
+
+func TestRecallScopeWritesSparingly(t *testing.T) {
+	root := t.TempDir()
+	project := ProjectNeoPath(root)
+	if out, err := recallScope(project, ScopeProject, "anything", 5); err != nil || out != "" {
+		t.Fatalf("unexpected recall %q (%v)", out, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".minibrain")); err == nil {
+		t.Fatal("recall should not create .minibrain without project memory")
+	}
+
+	neoPath := filepath.Join(t.TempDir(), "NEO.md")
+	if err := os.WriteFile(neoPath, []byte("- Deploys use docker compose\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := recallScope(neoPath, ScopeUser, "docker deploys", 5); err != nil || !strings.Contains(out, "docker compose") {
+		t.Fatalf("unexpected recall %q (%v)", out, err)
+	}
+	store := MemoryStorePath(neoPath)
+	before, err := os.Stat(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recallScope(neoPath, ScopeUser, "docker deploys", 5); err != nil {
+		t.Fatal(err)
+	}
+	// The store is replaced atomically, so a rewrite is a different file.
+	if after, err := os.Stat(store); err != nil || !os.SameFile(before, after) {
+		t.Fatalf("store rewritten on a second recall the same day (%v)", err)
+	}
+}
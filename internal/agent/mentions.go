@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"os"
 	"path/filepath"
@@ -9,7 +11,7 @@ import (
 )
 
 func ExtractFileMentions(prompt string) []string {
-	re := regexp.MustCompile(`@([A-Za-z0-9._/\-]+)`)
+	re := regexp.MustCompile(`@(commit:[A-Za-z0-9._/\-~^]+|[A-Za-z0-9._/\-]+)`)
 	matches := re.FindAllStringSubmatch(prompt, -1)
 	seen := map[string]struct{}{}
 	var out []string
@@ -25,23 +27,53 @@ func ExtractFileMentions(prompt string) []string {
 }
 
 func LoadMentionedFiles(root string, mentions []string, allowRead bool, maxFileBytes, maxTotalBytes int) []FileRef {
+	approve := func(string) error { return nil }
+	if !allowRead {
+		approve = func(string) error {
+			return errors.New("permission denied: reading file content requires approval")
+		}
+	}
+	return loadFileRefs(root, mentions, approve, maxFileBytes, maxTotalBytes)
+}
+
+// loadFileRefs loads mentions whose paths approve accepts. Git mentions are
+// approved by name, files by their path relative to root.
+func loadFileRefs(root string, mentions []string, approve func(rel string) error, maxFileBytes, maxTotalBytes int) []FileRef {
 	var refs []FileRef
 	total := 0
+	rules, rulesErr := LoadPathRules(root)
 	for _, m := range mentions {
+		if rulesErr != nil {
+			refs = append(refs, FileRef{Mention: m, Path: m, Err: rulesErr})
+			continue
+		}
+		if isGitMention(root, m) {
+			ref := loadGitMention(root, m, approve(m) == nil, maxFileBytes, rules)
+			if ref.Err == nil && maxTotalBytes > 0 && total+len(ref.Content) > maxTotalBytes {
+				ref = FileRef{Mention: m, Path: ref.Path, Err: errors.New("total read limit exceeded")}
+			}
+			total += len(ref.Content)
+			refs = append(refs, ref)
+			continue
+		}
 		resolved, ok := resolveMention(root, m)
 		if !ok {
 			refs = append(refs, FileRef{Mention: m, Path: m, Err: errors.New("not found")})
 			continue
 		}
-		if !allowRead {
-			refs = append(refs, FileRef{Mention: m, Path: resolved, Err: errors.New("permission denied: reading file content requires approval")})
-			continue
-		}
 		clean, err := safeRelPath(resolved)
 		if err != nil {
 			refs = append(refs, FileRef{Mention: m, Path: resolved, Err: err})
 			continue
 		}
+		if err := rules.CheckRead(clean); err != nil {
+			refs = append(refs, FileRef{Mention: m, Path: clean, Err: err})
+			continue
+		}
+		if err := approve(filepath.ToSlash(clean)); err != nil {
+			refs = append(refs, FileRef{Mention: m, Path: clean, Err: err})
+			continue
+		}
 		p := filepath.Join(root, clean)
 		info, err := os.Stat(p)
 		if err == nil && maxFileBytes > 0 && info.Size() > int64(maxFileBytes) {
@@ -66,7 +98,7 @@ func LoadMentionedFiles(root string, mentions []string, allowRead bool, maxFileB
 			continue
 		}
 		total += len(b)
-		refs = append(refs, FileRef{Mention: m, Path: clean, Content: string(b)})
+		refs = append(refs, FileRef{Mention: m, Path: clean, Content: string(b), Hash: hashContent(b)})
 	}
 	return refs
 }
@@ -133,8 +165,7 @@ func resolveMention(root, mention string) (string, bool) {
 			return nil
 		}
 		if d.IsDir() {
-			name := d.Name()
-			if name == ".git" || name == "node_modules" || name == "vendor" || name == "dist" || name == "build" || name == "bin" || name == "tmp" {
+			if skipWalkDir(d.Name()) {
 				return filepath.SkipDir
 			}
 			return nil
@@ -237,6 +268,11 @@ func min3(a, b, c int) int {
 	return c
 }
 
+func hashContent(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
 func isBinary(b []byte) bool {
 	n := len(b)
 	if n > 8000 {
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chrishannah/minibrain/internal/git"
+)
+
+// isGitMention reports whether m is one of the git mention types: @diff for
+// the working tree, @staged for the index and @commit:<rev> for a commit.
+// A real file with the same name under root wins.
+func isGitMention(root, m string) bool {
+	if m != "diff" && m != "staged" && !strings.HasPrefix(m, "commit:") {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(root, m))
+	return err != nil
+}
+
+func gitMentionLabel(m string) string {
+	switch {
+	case m == "diff":
+		return "git diff HEAD"
+	case m == "staged":
+		return "git diff --cached"
+	default:
+		return "git show " + strings.TrimPrefix(m, "commit:")
+	}
+}
+
+// loadGitMention runs the git command behind m. Output goes through the same
+// read approval, read rules and per-file byte limit as file content.
+func loadGitMention(root, m string, allowRead bool, maxBytes int, rules PathRules) FileRef {
+	ref := FileRef{Mention: m, Path: gitMentionLabel(m)}
+	if !allowRead {
+		ref.Err = errors.New("permission denied: reading file content requires approval")
+		return ref
+	}
+	repo, err := git.Open(root)
+	if err != nil {
+		ref.Err = err
+		return ref
+	}
+	var out string
+	switch {
+	case m == "diff":
+		out, err = repo.Diff(false)
+	case m == "staged":
+		out, err = repo.Diff(true)
+	default:
+		out, err = repo.Show(strings.TrimPrefix(m, "commit:"))
+	}
+	if err != nil {
+		ref.Err = err
+		return ref
+	}
+	prefix, err := git.Prefix(root)
+	if err != nil {
+		ref.Err = err
+		return ref
+	}
+	out = filterGitOutput(out, prefix, rules)
+	if strings.TrimSpace(out) == "" {
+		out = "(no changes)\n"
+	}
+	if maxBytes > 0 && len(out) > maxBytes {
+		ref.Err = fmt.Errorf("git output too large (%d bytes)", len(out))
+		return ref
+	}
+	ref.Content = out
+	return ref
+}
@@ -3,6 +3,7 @@ package agent
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -53,3 +54,85 @@ func TestLoadMentionedFilesPermission(t *testing.T) {
 		t.Fatal("expected permission denied error")
 	}
 }
+
+func TestExtractGitMentions(t *testing.T) {
+	out := ExtractFileMentions("review @diff and @staged against @commit:HEAD~1, see @a.txt")
+	want := []string{"diff", "staged", "commit:HEAD~1", "a.txt"}
+	if len(out) != len(want) {
+		t.Fatalf("unexpected mentions: %#v", out)
+	}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Fatalf("unexpected mentions: %#v", out)
+		}
+	}
+}
+
+func TestLoadGitMentions(t *testing.T) {
+	root := gitRepo(t)
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	refs := LoadMentionedFiles(root, []string{"diff", "staged", "commit:HEAD", "commit:-x"}, true, 0, 0)
+	if refs[0].Err != nil || !strings.Contains(refs[0].Content, "+two") || refs[0].Path != "git diff HEAD" {
+		t.Fatalf("unexpected diff ref: %+v", refs[0])
+	}
+	if refs[1].Err != nil || refs[1].Content != "(no changes)\n" {
+		t.Fatalf("unexpected staged ref: %+v", refs[1])
+	}
+	if refs[2].Err != nil || !strings.Contains(refs[2].Content, "+one") {
+		t.Fatalf("unexpected commit ref: %+v", refs[2])
+	}
+	if refs[3].Err == nil {
+		t.Fatal("expected option-like revision to be rejected")
+	}
+
+	denied := LoadMentionedFiles(root, []string{"diff"}, false, 0, 0)
+	if denied[0].Err == nil || denied[0].Content != "" {
+		t.Fatalf("expected read approval to be required: %+v", denied[0])
+	}
+	limited := LoadMentionedFiles(root, []string{"diff"}, true, 10, 0)
+	if limited[0].Err == nil {
+		t.Fatal("expected byte limit to apply")
+	}
+}
+
+func TestGitMentionFromSubdirectory(t *testing.T) {
+	repo := gitRepo(t)
+	root := filepath.Join(repo, "sub")
+	for _, dir := range []string{".minibrain", "keys"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range map[string]string{"keys/secret.txt": "one\n", "main.go": "package main\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun(t, repo, "add", "sub")
+	gitRun(t, repo, "commit", "-q", "-m", "sub")
+	if err := os.WriteFile(ProjectConfigPath(root), []byte(`{"permissions": {"read": {"deny": ["keys/secret.txt"]}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"keys/secret.txt": "TOKEN=x\n", "main.go": "package sub\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	refs := LoadMentionedFiles(root, []string{"diff"}, true, 0, 0)
+	if refs[0].Err != nil || strings.Contains(refs[0].Content, "TOKEN") || !strings.Contains(refs[0].Content, "+package sub") {
+		t.Fatalf("unexpected diff ref: %+v", refs[0])
+	}
+}
+
+func TestFileNamedLikeGitMention(t *testing.T) {
+	root := gitRepo(t)
+	if err := os.WriteFile(filepath.Join(root, "diff"), []byte("a file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	refs := LoadMentionedFiles(root, []string{"diff"}, true, 0, 0)
+	if refs[0].Err != nil || refs[0].Content != "a file\n" {
+		t.Fatalf("expected the file, got %+v", refs[0])
+	}
+}
@@ -0,0 +1,103 @@
+package agent
+
+import "strings"
+
+const (
+	conflictStart = "<<<<<<< current (on disk)"
+	conflictMid   = "======="
+	conflictEnd   = ">>>>>>> minibrain"
+)
+
+// Merge3 merges ours and theirs, which both started from base. Regions that
+// only one side changed are taken from that side; regions both sides changed
+// differently are wrapped in conflict markers.
+func Merge3(base, ours, theirs string) (string, int) {
+	baseLines := splitLines(base)
+	ourLines := splitLines(ours)
+	theirLines := splitLines(theirs)
+	matchOurs := matchLines(baseLines, ourLines)
+	matchTheirs := matchLines(baseLines, theirLines)
+
+	var out []string
+	conflicts := 0
+	i, a, b := 0, 0, 0
+	for i < len(baseLines) || a < len(ourLines) || b < len(theirLines) {
+		if i < len(baseLines) && matchOurs[i] == a && matchTheirs[i] == b {
+			out = append(out, baseLines[i])
+			i++
+			a++
+			b++
+			continue
+		}
+		k := i
+		for k < len(baseLines) && (matchOurs[k] < 0 || matchTheirs[k] < 0) {
+			k++
+		}
+		endA, endB := len(ourLines), len(theirLines)
+		if k < len(baseLines) {
+			endA, endB = matchOurs[k], matchTheirs[k]
+		}
+		baseChunk := baseLines[i:k]
+		ourChunk := ourLines[a:endA]
+		theirChunk := theirLines[b:endB]
+		switch {
+		case equalLines(ourChunk, baseChunk):
+			out = append(out, theirChunk...)
+		case equalLines(theirChunk, baseChunk), equalLines(ourChunk, theirChunk):
+			out = append(out, ourChunk...)
+		default:
+			conflicts++
+			out = append(out, conflictStart)
+			out = append(out, ourChunk...)
+			out = append(out, conflictMid)
+			out = append(out, theirChunk...)
+			out = append(out, conflictEnd)
+		}
+		i, a, b = k, endA, endB
+	}
+
+	result := strings.Join(out, "\n")
+	if len(out) > 0 && (strings.HasSuffix(ours, "\n") || (ours == "" && strings.HasSuffix(theirs, "\n"))) {
+		result += "\n"
+	}
+	return result, conflicts
+}
+
+// ConflictRegions returns the marked regions of a merge result with a few
+// lines of surrounding context, for previews.
+func ConflictRegions(merged string, context int) [][]string {
+	lines := splitLines(merged)
+	var regions [][]string
+	for i := 0; i < len(lines); i++ {
+		if lines[i] != conflictStart {
+			continue
+		}
+		end := i
+		for end < len(lines) && lines[end] != conflictEnd {
+			end++
+		}
+		from := i - context
+		if from < 0 {
+			from = 0
+		}
+		to := end + 1 + context
+		if to > len(lines) {
+			to = len(lines)
+		}
+		regions = append(regions, append([]string(nil), lines[from:to]...))
+		i = end
+	}
+	return regions
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
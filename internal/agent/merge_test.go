@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMerge3Clean(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	ours := "a\nB\nc\nd\ne\n"
+	theirs := "a\nb\nc\nd\nE\n"
+	merged, conflicts := Merge3(base, ours, theirs)
+	if conflicts != 0 {
+		t.Fatalf("expected clean merge, got %d conflicts:\n%s", conflicts, merged)
+	}
+	if merged != "a\nB\nc\nd\nE\n" {
+		t.Fatalf("unexpected merge: %q", merged)
+	}
+}
+
+func TestMerge3Conflict(t *testing.T) {
+	base := "a\nb\nc\n"
+	ours := "a\nmine\nc\n"
+	theirs := "a\ntheirs\nc\n"
+	merged, conflicts := Merge3(base, ours, theirs)
+	if conflicts != 1 {
+		t.Fatalf("expected 1 conflict, got %d", conflicts)
+	}
+	want := "a\n" + conflictStart + "\nmine\n" + conflictMid + "\ntheirs\n" + conflictEnd + "\nc\n"
+	if merged != want {
+		t.Fatalf("unexpected merge:\n%s", merged)
+	}
+	regions := ConflictRegions(merged, 1)
+	if len(regions) != 1 || regions[0][0] != "a" || !strings.HasPrefix(regions[0][1], "<<<<<<<") {
+		t.Fatalf("unexpected regions: %#v", regions)
+	}
+}
+
+func TestApplyChangeSetMergesStaleFile(t *testing.T) {
+	root := t.TempDir()
+	p := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(p, []byte("one\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	refs := LoadMentionedFiles(root, []string{"a.txt"}, true, 0, 0)
+	if err := os.WriteFile(p, []byte("ONE\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatalf("write edit: %v", err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{
+		Patches: []PatchOp{{Path: "a.txt", Patch: "@@ -3,2 +3,2 @@\n three\n-four\n+FOUR"}},
+		Reads:   refs,
+	})
+	if !report.Committed {
+		t.Fatalf("expected merge to commit, got %#v", report.Results)
+	}
+	b, _ := os.ReadFile(p)
+	if string(b) != "ONE\ntwo\nthree\nFOUR\n" {
+		t.Fatalf("unexpected merged content: %q", string(b))
+	}
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathRules are per-action glob rules from .minibrain/config.json. Deny
+// rules always win; a non-empty allow list also denies every path it does
+// not match. Patches and edits are checked as writes.
+type PathRules struct {
+	Read   RuleSet `json:"read,omitzero"`
+	Write  RuleSet `json:"write,omitzero"`
+	Delete RuleSet `json:"delete,omitzero"`
+}
+
+type RuleSet struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// LoadPathRules reads the rules for the project at root. The apply and load
+// functions call it themselves so rules hold whichever front end is used,
+// and refuse to touch files when the config cannot be read.
+func LoadPathRules(root string) (PathRules, error) {
+	cfg, err := LoadProjectConfig(root)
+	return cfg.Permissions, err
+}
+
+func (r PathRules) CheckRead(rel string) error   { return r.Read.check("read", rel) }
+func (r PathRules) CheckWrite(rel string) error  { return r.Write.check("write", rel) }
+func (r PathRules) CheckDelete(rel string) error { return r.Delete.check("delete", rel) }
+
+// checkOp checks a change set op kind: deletes as deletes, the rest as
+// writes.
+func (r PathRules) checkOp(kind, rel string) error {
+	switch kind {
+	case "DELETE":
+		return r.CheckDelete(rel)
+	default:
+		return r.CheckWrite(rel)
+	}
+}
+
+func (s RuleSet) check(action, rel string) error {
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	for _, p := range s.Deny {
+		// A malformed deny rule fails closed.
+		if ok, err := matchRule(p, rel); ok || err != nil {
+			return fmt.Errorf("permission denied: %s of %s blocked by rule %q", action, rel, p)
+		}
+	}
+	if len(s.Allow) == 0 {
+		return nil
+	}
+	for _, p := range s.Allow {
+		if ok, _ := matchRule(p, rel); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("permission denied: %s of %s is outside the allowed paths", action, rel)
+}
+
+// matchRule matches a slash-separated glob against rel. "**" spans any
+// number of directories, a pattern without a slash matches a name at any
+// depth, and a pattern that matches a directory covers everything in it.
+func matchRule(pattern, rel string) (bool, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pattern)), "./")
+	if pattern == "" {
+		return false, nil
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	pat := strings.Split(pattern, "/")
+	parts := strings.Split(rel, "/")
+	for n := len(parts); n > 0; n-- {
+		ok, err := matchSegments(pat, parts[:n])
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func matchSegments(pat, parts []string) (bool, error) {
+	if len(pat) == 0 {
+		return len(parts) == 0, nil
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if ok, err := matchSegments(pat[1:], parts[i:]); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	if len(parts) == 0 {
+		return false, nil
+	}
+	ok, err := path.Match(pat[0], parts[0])
+	if !ok || err != nil {
+		return false, err
+	}
+	return matchSegments(pat[1:], parts[1:])
+}
+
+// filterGitOutput drops the per-file sections of a diff or `git show` whose
+// paths the read rules deny, leaving a note in their place. Git reports
+// paths from the repo top level; prefix is the project root's path below it.
+func filterGitOutput(out, prefix string, rules PathRules) string {
+	if len(rules.Read.Allow) == 0 && len(rules.Read.Deny) == 0 {
+		return out
+	}
+	var b strings.Builder
+	skip := false
+	for _, line := range strings.SplitAfter(out, "\n") {
+		if rest, ok := strings.CutPrefix(line, "diff --git "); ok {
+			skip = false
+			for _, f := range strings.Fields(rest) {
+				f = strings.TrimPrefix(strings.TrimPrefix(f, "a/"), "b/")
+				if err := rules.CheckRead(rootRelative(prefix, f)); err != nil {
+					skip = true
+					b.WriteString("(diff for " + f + " omitted by permission rules)\n")
+					break
+				}
+			}
+		}
+		if !skip {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// rootRelative converts a path relative to the repo top level to one
+// relative to the project root at prefix.
+func rootRelative(prefix, p string) string {
+	if rel, ok := strings.CutPrefix(p, prefix); ok {
+		return rel
+	}
+	return strings.Repeat("../", strings.Count(prefix, "/")) + p
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchRule(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"**/.env*", ".env", true},
+		{"**/.env*", "config/.env.local", true},
+		{".env*", "deep/dir/.envrc", true},
+		{"secrets/**", "secrets/db/key.pem", true},
+		{"secrets/", "secrets/key.pem", true},
+		{"secrets", "app/secrets/key.pem", true},
+		{"secrets/**", "app/secrets/key.pem", false},
+		{"internal/**", "internal/agent/agent.go", true},
+		{"internal/**", "cmd/main.go", false},
+		{"*.go", "cmd/minibrain/main.go", true},
+		{"cmd/*.go", "cmd/minibrain/main.go", false},
+		{"cmd/**/*.go", "cmd/minibrain/main.go", true},
+	}
+	for _, c := range cases {
+		if got, err := matchRule(c.pattern, c.path); err != nil || got != c.want {
+			t.Fatalf("%q vs %q: got %v %v, want %v", c.pattern, c.path, got, err, c.want)
+		}
+	}
+}
+
+func TestPathRulesDenyWins(t *testing.T) {
+	rules := PathRules{
+		Read:  RuleSet{Deny: []string{"**/.env*", "secrets/**"}},
+		Write: RuleSet{Allow: []string{"internal/**"}, Deny: []string{"internal/vault/**"}},
+	}
+	if err := rules.CheckRead("main.go"); err != nil {
+		t.Fatalf("expected read allowed: %v", err)
+	}
+	if err := rules.CheckRead("secrets/token"); err == nil || !strings.Contains(err.Error(), `"secrets/**"`) {
+		t.Fatalf("expected read denied by rule, got %v", err)
+	}
+	if err := rules.CheckWrite("internal/agent/a.go"); err != nil {
+		t.Fatalf("expected write allowed: %v", err)
+	}
+	if err := rules.CheckWrite("internal/vault/vault.go"); err == nil {
+		t.Fatal("expected deny to win over allow")
+	}
+	if err := rules.CheckWrite("README.md"); err == nil {
+		t.Fatal("expected write outside the allow list to be denied")
+	}
+	if err := rules.CheckDelete("README.md"); err != nil {
+		t.Fatalf("expected deletes unrestricted: %v", err)
+	}
+	if err := (RuleSet{Deny: []string{"[bad"}}).check("read", "a.go"); err == nil {
+		t.Fatal("expected a malformed deny rule to fail closed")
+	}
+}
+
+func TestPathRulesEnforced(t *testing.T) {
+	root := t.TempDir()
+	if err := SaveProjectConfig(root, ProjectConfig{Permissions: PathRules{
+		Read:   RuleSet{Deny: []string{".env*"}},
+		Write:  RuleSet{Allow: []string{"internal/**"}},
+		Delete: RuleSet{Deny: []string{"**"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{".env": "TOKEN=x\n", "README.md": "one\n", "internal/a.go": "package a\n"} {
+		p := filepath.Join(root, name)
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refs := LoadMentionedFiles(root, []string{".env", "README.md"}, true, 0, 0)
+	if refs[0].Err == nil || refs[0].Content != "" || refs[1].Err != nil {
+		t.Fatalf("expected only .env to be blocked, got %+v", refs)
+	}
+
+	if report := ApplyChangeSet(root, ChangeSet{Writes: []WriteOp{{Path: "internal/b.go", Content: "package a\n"}}}); !report.Committed {
+		t.Fatalf("expected the internal write, got %+v", report.Results)
+	}
+	if report := ApplyChangeSet(root, ChangeSet{Writes: []WriteOp{{Path: "README.md", Content: "two\n"}}}); report.Committed || report.Results[0].Status != OpFailed {
+		t.Fatalf("expected the README.md write to be blocked, got %+v", report.Results)
+	}
+	if report := ApplyChangeSet(root, ChangeSet{Deletes: []DeleteOp{{Path: "internal/b.go"}}}); report.Committed {
+		t.Fatal("expected the delete to be blocked")
+	}
+	patch := "--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-one\n+two\n"
+	if failed := ApplyChangeSet(root, ChangeSet{Patches: []PatchOp{{Path: "README.md", Patch: patch}}}).PatchFailures(); len(failed) != 1 || !strings.Contains(failed[0].Reason, "outside the allowed paths") {
+		t.Fatalf("expected the patch to be blocked, got %+v", failed)
+	}
+
+	report := ApplyChangeSet(root, ChangeSet{
+		Writes: []WriteOp{{Path: "internal/c.go", Content: "package a\n"}},
+		Edits:  []EditOp{{Path: "README.md", OldString: "one", NewString: "two"}},
+	})
+	if report.Committed || report.Results[1].Status != OpFailed {
+		t.Fatalf("expected the change set to be rejected, got %+v", report.Results)
+	}
+	if _, err := os.Stat(filepath.Join(root, "internal", "c.go")); !os.IsNotExist(err) {
+		t.Fatal("expected no partial writes")
+	}
+	if b, _ := os.ReadFile(filepath.Join(root, "README.md")); string(b) != "one\n" {
+		t.Fatalf("README.md changed: %q", b)
+	}
+}
+
+func TestFilterGitOutput(t *testing.T) {
+	out := "commit abc\n\ndiff --git a/.env b/.env\n+TOKEN=x\ndiff --git a/main.go b/main.go\n+package main\n"
+	got := filterGitOutput(out, "", PathRules{Read: RuleSet{Deny: []string{".env"}}})
+	if strings.Contains(got, "TOKEN") || !strings.Contains(got, "+package main") || !strings.Contains(got, "omitted by permission rules") {
+		t.Fatalf("unexpected filtered output:\n%s", got)
+	}
+}
+
+func TestPathRulesFailClosedOnBadConfig(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".minibrain"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ProjectConfigPath(root), []byte(`{"permissions": {"read": {"deny": [".env"]}},}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("TOKEN=x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadProjectConfig(root); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if refs := LoadMentionedFiles(root, []string{".env"}, true, 0, 0); refs[0].Err == nil || refs[0].Content != "" {
+		t.Fatalf("expected the read to be refused, got %+v", refs)
+	}
+	report := ApplyChangeSet(root, ChangeSet{Writes: []WriteOp{{Path: "a.txt", Content: "a\n"}}})
+	if report.Committed || report.Results[0].Status != OpFailed {
+		t.Fatalf("expected the change set to be refused, got %+v", report.Results)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+		t.Fatal("expected nothing to be written")
+	}
+}
@@ -1,14 +1,20 @@
 package agent
 
+// PermissionState is the starting read and write permission. The sources
+// name what granted each, for the audit log.
 type PermissionState struct {
-	Project    ProjectConfig
-	AllowRead  bool
-	AllowWrite bool
-	DenyWrite  bool
+	Project     ProjectConfig
+	AllowRead   bool
+	AllowWrite  bool
+	DenyWrite   bool
+	ReadSource  string
+	WriteSource string
+	Err         error
 }
 
 func ResolvePermissionState(root string, envRead, envWrite bool) PermissionState {
-	proj := LoadProjectConfig(root)
+	// A config that does not parse grants nothing.
+	proj, err := LoadProjectConfig(root)
 	allowRead := envRead || proj.AllowReadAlways
 	allowWrite := envWrite || proj.AllowWriteAlways
 	denyWrite := proj.DenyWriteAlways
@@ -16,9 +22,23 @@ func ResolvePermissionState(root string, envRead, envWrite bool) PermissionState
 		allowWrite = false
 	}
 	return PermissionState{
-		Project:    proj,
-		AllowRead:  allowRead,
-		AllowWrite: allowWrite,
-		DenyWrite:  denyWrite,
+		Project:     proj,
+		AllowRead:   allowRead,
+		AllowWrite:  allowWrite,
+		DenyWrite:   denyWrite,
+		ReadSource:  permissionSource(envRead, "MINIBRAIN_ALLOW_READ", proj.AllowReadAlways, "allow_read_always"),
+		WriteSource: permissionSource(envWrite, "MINIBRAIN_ALLOW_WRITE", proj.AllowWriteAlways, "allow_write_always"),
+		Err:         err,
+	}
+}
+
+func permissionSource(env bool, envName string, project bool, key string) string {
+	switch {
+	case env:
+		return envName
+	case project:
+		return key
+	default:
+		return ""
 	}
 }
@@ -2,39 +2,71 @@ package agent
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
 type ProjectConfig struct {
-	AllowReadAlways  bool `json:"allow_read_always"`
-	AllowWriteAlways bool `json:"allow_write_always"`
-	DenyWriteAlways  bool `json:"deny_write_always"`
+	AllowReadAlways  bool          `json:"allow_read_always"`
+	AllowWriteAlways bool          `json:"allow_write_always"`
+	DenyWriteAlways  bool          `json:"deny_write_always"`
+	GitMode          bool          `json:"git_mode,omitempty"`
+	GitBranch        bool          `json:"git_branch,omitempty"`
+	GitStash         bool          `json:"git_stash,omitempty"`
+	RedactPatterns   []string      `json:"redact_patterns,omitempty"`
+	Permissions      PathRules     `json:"permissions,omitzero"`
+	ReadApprovals    ReadApprovals `json:"read_approvals,omitzero"`
 }
 
-func LoadProjectConfig(root string) ProjectConfig {
+// LoadProjectConfig reads .minibrain/config.json. A missing file is an empty
+// config; a file that does not parse is an error, so path rules and saved
+// answers are never silently dropped.
+func LoadProjectConfig(root string) (ProjectConfig, error) {
 	path := ProjectConfigPath(root)
 	b, err := os.ReadFile(path)
 	if err != nil {
-		return ProjectConfig{}
+		if errors.Is(err, os.ErrNotExist) {
+			return ProjectConfig{}, nil
+		}
+		return ProjectConfig{}, err
 	}
 	var cfg ProjectConfig
 	if err := json.Unmarshal(b, &cfg); err != nil {
-		return ProjectConfig{}
+		return ProjectConfig{}, fmt.Errorf("invalid %s: %w", path, err)
 	}
-	return cfg
+	return cfg, nil
 }
 
 func SaveProjectConfig(root string, cfg ProjectConfig) error {
 	path := ProjectConfigPath(root)
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return err
-	}
+	return withFileLock(path, func() error { return writeProjectConfig(path, cfg) })
+}
+
+// UpdateProjectConfig re-reads the config under its lock, applies fn and
+// writes the result atomically, so edits made to the file meanwhile are
+// kept. It refuses to overwrite a file that does not parse.
+func UpdateProjectConfig(root string, fn func(*ProjectConfig)) (ProjectConfig, error) {
+	path := ProjectConfigPath(root)
+	var cfg ProjectConfig
+	err := withFileLock(path, func() error {
+		var err error
+		if cfg, err = LoadProjectConfig(root); err != nil {
+			return err
+		}
+		fn(&cfg)
+		return writeProjectConfig(path, cfg)
+	})
+	return cfg, err
+}
+
+func writeProjectConfig(path string, cfg ProjectConfig) error {
 	b, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, b, 0644)
+	return atomicWriteFile(path, b, 0644)
 }
 
 func ProjectConfigPath(root string) string {
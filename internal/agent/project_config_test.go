@@ -22,7 +22,10 @@ func TestProjectConfigSaveLoad(t *testing.T) {
 		t.Fatalf("expected config file to exist: %v", err)
 	}
 
-	loaded := LoadProjectConfig(dir)
+	loaded, err := LoadProjectConfig(dir)
+	if err != nil {
+		t.Fatalf("load project config: %v", err)
+	}
 	if loaded.AllowReadAlways != cfg.AllowReadAlways {
 		t.Fatalf("AllowReadAlways mismatch: got %v want %v", loaded.AllowReadAlways, cfg.AllowReadAlways)
 	}
@@ -42,3 +45,28 @@ func TestProjectConfigPath(t *testing.T) {
 		t.Fatalf("path mismatch: got %q want %q", got, want)
 	}
 }
+
+func TestUpdateProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := SaveProjectConfig(dir, ProjectConfig{RedactPatterns: []string{"tok-[0-9]+"}}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := UpdateProjectConfig(dir, func(c *ProjectConfig) { c.ReadApprovals.Add("docs/**", true) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.RedactPatterns) != 1 || len(cfg.ReadApprovals.Allow) != 1 {
+		t.Fatalf("expected existing settings kept, got %+v", cfg)
+	}
+
+	bad := []byte(`{"redact_patterns": ["tok"],`)
+	if err := os.WriteFile(ProjectConfigPath(dir), bad, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpdateProjectConfig(dir, func(c *ProjectConfig) { c.AllowReadAlways = true }); err == nil {
+		t.Fatal("expected a config that does not parse to be left alone")
+	}
+	if b, _ := os.ReadFile(ProjectConfigPath(dir)); string(b) != string(bad) {
+		t.Fatalf("config was overwritten: %s", b)
+	}
+}
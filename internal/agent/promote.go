@@ -0,0 +1,415 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/llm"
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// PromotionOp adds or removes one fact in the NEO file at Path.
+type PromotionOp struct {
+	Scope MemoryScope `json:"scope"`
+	Fact  string      `json:"fact"`
+	Path  string      `json:"path"`
+}
+
+// Promotion is a proposed set of long-term memory changes, kept pending in
+// cortex/promotion.json until it is accepted or rejected.
+type Promotion struct {
+	Add     []PromotionOp `json:"add"`
+	Remove  []PromotionOp `json:"remove"`
+	Created string        `json:"created"`
+}
+
+func (p Promotion) Empty() bool {
+	return len(p.Add) == 0 && len(p.Remove) == 0
+}
+
+var promotionSchema = json.RawMessage(`{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "add": { "type": "array", "items": { "$ref": "#/$defs/fact" } },
+    "remove": { "type": "array", "items": { "$ref": "#/$defs/fact" } }
+  },
+  "required": ["add", "remove"],
+  "$defs": {
+    "fact": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "scope": { "type": "string", "enum": ["user", "project"] },
+        "fact": { "type": "string" }
+      },
+      "required": ["scope", "fact"]
+    }
+  }
+}`)
+
+const promotionInstructions = "You maintain the long-term memory of a coding assistant. " +
+	"From the session notes, extract durable facts, preferences and constraints that will still matter in future sessions. " +
+	"Skip transient task details, progress updates and anything already in memory. " +
+	"Use scope \"project\" for facts about this repository and \"user\" for personal preferences that apply to every project. " +
+	"Under remove, quote existing memory entries that the session shows are wrong or obsolete, exactly as written. " +
+	"Each fact is one short sentence. Return empty lists when nothing qualifies."
+
+func PendingPromotionPath(brainDir string) string {
+	return filepath.Join(brainDir, "cortex", "promotion.json")
+}
+
+// PromoteShortTerm proposes memory changes from the current PREFRONTAL.
+func PromoteShortTerm(cfg Config) (Promotion, bool, error) {
+	stm, err := readFileOrEmpty(resolvePrefrontalPath(cfg))
+	if err != nil {
+		return Promotion{}, false, err
+	}
+	return promoteFrom(cfg, stm)
+}
+
+// promoteFrom runs the promotion pass over stm. With AutoPromote the result
+// is applied straight away; otherwise it is merged into the pending proposal.
+// It reports whether the changes were applied.
+func promoteFrom(cfg Config, stm string) (Promotion, bool, error) {
+	if strings.TrimSpace(stm) == "" {
+		return Promotion{}, false, nil
+	}
+	p, err := ProposePromotion(cfg, stm)
+	if err != nil || p.Empty() {
+		return p, false, err
+	}
+	if cfg.AutoPromote {
+		_, _, err := ApplyPromotion(cfg.BrainDir, p)
+		return p, err == nil, err
+	}
+	err = withFileLock(PendingPromotionPath(cfg.BrainDir), func() error {
+		pending, _ := LoadPendingPromotion(cfg.BrainDir)
+		merged := normalizePromotion(cfg, Promotion{
+			Add:    append(pending.Add, p.Add...),
+			Remove: append(pending.Remove, p.Remove...),
+		})
+		return SavePendingPromotion(cfg.BrainDir, merged)
+	})
+	return p, false, err
+}
+
+func ProposePromotion(cfg Config, stm string) (Promotion, error) {
+	userNeo, _ := readFileOrEmpty(resolveNeoPath(cfg))
+	projectNeo, _ := readFileOrEmpty(resolveProjectNeoPath(cfg))
+	var in strings.Builder
+	in.WriteString("User memory (user scope):\n" + orEmpty(userNeo) + "\n\n")
+	in.WriteString("Project memory (project scope):\n" + orEmpty(projectNeo) + "\n\n")
+	in.WriteString("Session notes:\n" + stm + "\n")
+
+	model := cfg.Model
+	if model == "" {
+		model = "gpt-4.1"
+	}
+	ctx, cancel := contextWithTimeout(cfg.TimeoutSec)
+	defer cancel()
+	out, err := llm.CallOpenAIJSON(ctx, model, promotionInstructions, redactText(cfg, cfg.BrainDir, "promotion", in.String()), "minibrain_promotion", promotionSchema)
+	if err != nil {
+		return Promotion{}, err
+	}
+	var raw Promotion
+	if err := json.Unmarshal([]byte(out), &raw); err != nil {
+		return Promotion{}, errors.New("model returned invalid promotion JSON")
+	}
+	for i := range raw.Add {
+		raw.Add[i].Fact = redactText(cfg, cfg.BrainDir, "memory", raw.Add[i].Fact)
+	}
+	return normalizePromotion(cfg, raw), nil
+}
+
+// normalizePromotion resolves scopes and paths, and drops additions that are
+// already remembered, removals of facts that are not there, and duplicates.
+func normalizePromotion(cfg Config, p Promotion) Promotion {
+	paths := map[MemoryScope]string{
+		ScopeUser:    resolveNeoPath(cfg),
+		ScopeProject: resolveProjectNeoPath(cfg),
+	}
+	contents := map[string]string{}
+	content := func(path string) string {
+		if c, ok := contents[path]; !ok {
+			c, _ = readFileOrEmpty(path)
+			contents[path] = c
+		}
+		return contents[path]
+	}
+	out := Promotion{Created: p.Created}
+	if out.Created == "" {
+		out.Created = time.Now().UTC().Format(time.RFC3339)
+	}
+	seen := map[string]bool{}
+	for _, op := range p.Add {
+		op.Fact = normalizeFact(op.Fact)
+		if op.Fact == "" {
+			continue
+		}
+		if op.Scope != ScopeUser && op.Scope != ScopeProject {
+			op.Scope = ClassifyMemoryScope(cfg.RootDir, op.Fact)
+		}
+		if op.Path == "" {
+			op.Path = paths[op.Scope]
+		}
+		key := "add\x00" + op.Path + "\x00" + strings.ToLower(op.Fact)
+		if op.Path == "" || seen[key] || hasFact(content(op.Path), op.Fact) {
+			continue
+		}
+		seen[key] = true
+		out.Add = append(out.Add, op)
+	}
+	for _, op := range p.Remove {
+		op.Fact = normalizeFact(op.Fact)
+		if op.Fact == "" {
+			continue
+		}
+		if op.Path == "" {
+			for _, scope := range []MemoryScope{op.Scope, ScopeProject, ScopeUser} {
+				if path := paths[scope]; path != "" && hasFact(content(path), op.Fact) {
+					op.Scope, op.Path = scope, path
+					break
+				}
+			}
+		}
+		key := "remove\x00" + op.Path + "\x00" + strings.ToLower(op.Fact)
+		if op.Path == "" || seen[key] || !hasFact(content(op.Path), op.Fact) {
+			continue
+		}
+		seen[key] = true
+		out.Remove = append(out.Remove, op)
+	}
+	return out
+}
+
+func normalizeFact(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "- ")
+	s = strings.TrimPrefix(s, "* ")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func hasFact(content, fact string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.EqualFold(normalizeFact(line), fact) {
+			return true
+		}
+	}
+	return false
+}
+
+func LoadPendingPromotion(brainDir string) (Promotion, error) {
+	b, err := vault.ReadFile(PendingPromotionPath(brainDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Promotion{}, nil
+		}
+		return Promotion{}, err
+	}
+	var p Promotion
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Promotion{}, err
+	}
+	return p, nil
+}
+
+func SavePendingPromotion(brainDir string, p Promotion) error {
+	if p.Empty() {
+		return ClearPendingPromotion(brainDir)
+	}
+	if err := ensurePrivateDir(filepath.Join(brainDir, "cortex")); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(PendingPromotionPath(brainDir), b, 0600)
+}
+
+func ClearPendingPromotion(brainDir string) error {
+	err := os.Remove(PendingPromotionPath(brainDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// ApplyPromotion writes the changes to the NEO files, records them in the
+// memory log under brainDir, and reports how many facts were added and
+// removed.
+func ApplyPromotion(brainDir string, p Promotion) (int, int, error) {
+	var added, removed int
+	for _, path := range promotionPaths(p) {
+		scope := ScopeUser
+		var adds, removes []PromotionOp
+		for _, op := range p.Add {
+			if op.Path == path {
+				adds = append(adds, op)
+				scope = op.Scope
+			}
+		}
+		for _, op := range p.Remove {
+			if op.Path == path {
+				removes = append(removes, op)
+				scope = op.Scope
+			}
+		}
+		var addedEntries, removedEntries []MemoryEntry
+		_, err := UpdateMemories(path, scope, func(entries []MemoryEntry) ([]MemoryEntry, error) {
+			for _, op := range removes {
+				if i := findMemory(entries, op.Fact); i >= 0 {
+					removedEntries = append(removedEntries, entries[i])
+					entries = append(entries[:i], entries[i+1:]...)
+				}
+			}
+			for _, op := range adds {
+				if findMemory(entries, op.Fact) < 0 {
+					e := NewMemoryEntry(entries, op.Fact, "promotion", op.Scope, nil)
+					addedEntries = append(addedEntries, e)
+					entries = append(entries, e)
+				}
+			}
+			return entries, nil
+		})
+		if err != nil {
+			return added, removed, err
+		}
+		added += len(addedEntries)
+		removed += len(removedEntries)
+		if len(removedEntries) > 0 {
+			if _, err := logMemoryChange(brainDir, MemoryChange{Action: MemoryForget, Source: "promotion", Scope: scope, Path: path, Entries: removedEntries}); err != nil {
+				return added, removed, err
+			}
+		}
+		if len(addedEntries) > 0 {
+			if _, err := logMemoryChange(brainDir, MemoryChange{Action: MemoryAdd, Source: "promotion", Scope: scope, Path: path, Entries: addedEntries}); err != nil {
+				return added, removed, err
+			}
+		}
+	}
+	return added, removed, nil
+}
+
+// AcceptPendingPromotion applies the pending proposal and clears it.
+func AcceptPendingPromotion(brainDir string) (int, int, error) {
+	var added, removed int
+	err := withFileLock(PendingPromotionPath(brainDir), func() error {
+		p, err := LoadPendingPromotion(brainDir)
+		if err != nil {
+			return err
+		}
+		if p.Empty() {
+			return errors.New("no pending memory changes")
+		}
+		if added, removed, err = ApplyPromotion(brainDir, p); err != nil {
+			return err
+		}
+		return ClearPendingPromotion(brainDir)
+	})
+	return added, removed, err
+}
+
+// PromotionDiff renders the proposal as a unified diff per NEO file.
+func PromotionDiff(p Promotion) string {
+	var b strings.Builder
+	for _, path := range promotionPaths(p) {
+		old, _ := readFileOrEmpty(path)
+		updated, _, _ := promotedContent(old, p, path)
+		b.WriteString(UnifiedDiff(path, old, updated, 1))
+	}
+	return b.String()
+}
+
+func promotionPaths(p Promotion) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, op := range append(append([]PromotionOp{}, p.Remove...), p.Add...) {
+		if op.Path != "" && !seen[op.Path] {
+			seen[op.Path] = true
+			out = append(out, op.Path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func promotedContent(old string, p Promotion, path string) (string, int, int) {
+	var added, removed int
+	lines := strings.SplitAfter(old, "\n")
+	var kept []string
+	for _, line := range lines {
+		drop := false
+		for _, op := range p.Remove {
+			if op.Path == path && strings.EqualFold(normalizeFact(line), op.Fact) {
+				drop = true
+				break
+			}
+		}
+		if drop {
+			removed++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	content := strings.Join(kept, "")
+	for _, op := range p.Add {
+		if op.Path != path || hasFact(content, op.Fact) {
+			continue
+		}
+		if content == "" {
+			content = neoHeader(op.Scope)
+		} else if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += "- " + op.Fact + "\n"
+		added++
+	}
+	return content, added, removed
+}
+
+func neoHeader(scope MemoryScope) string {
+	if scope == ScopeProject {
+		return "# Project Memory (NEO)\n\n"
+	}
+	return "# Long-Term Memory (NEO)\n\n"
+}
+
+func FormatPromotionSummary(p Promotion) string {
+	var parts []string
+	if n := len(p.Add); n > 0 {
+		parts = append(parts, pluralize(n, "addition"))
+	}
+	if n := len(p.Remove); n > 0 {
+		parts = append(parts, pluralize(n, "removal"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func pluralize(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return strconv.Itoa(n) + " " + word + "s"
+}
+
+func resolvePrefrontalPath(cfg Config) string {
+	if cfg.PrefrontalPath != "" {
+		return cfg.PrefrontalPath
+	}
+	return filepath.Join(cfg.BrainDir, "cortex", "PREFRONTAL.md")
+}
+
+func orEmpty(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "(empty)"
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func promoteConfig(t *testing.T) Config {
+	t.Helper()
+	cfg := Config{RootDir: t.TempDir(), BrainDir: t.TempDir()}
+	if err := os.MkdirAll(filepath.Join(cfg.BrainDir, "cortex"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(resolveNeoPath(cfg), []byte("# Long-Term Memory (NEO)\n\n- User prefers concise answers\n"), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	return cfg
+}
+
+func TestNormalizePromotionDedupes(t *testing.T) {
+	cfg := promoteConfig(t)
+	p := normalizePromotion(cfg, Promotion{
+		Add: []PromotionOp{
+			{Scope: ScopeUser, Fact: "- user prefers  concise answers"},
+			{Scope: ScopeProject, Fact: "Tests run with make test"},
+			{Scope: ScopeProject, Fact: "tests run with make test"},
+			{Fact: "The repo uses sqlc"},
+			{Scope: ScopeUser, Fact: "  "},
+		},
+		Remove: []PromotionOp{
+			{Fact: "User prefers concise answers"},
+			{Scope: ScopeProject, Fact: "Not in memory"},
+		},
+	})
+	if len(p.Add) != 2 {
+		t.Fatalf("expected 2 additions, got %#v", p.Add)
+	}
+	if p.Add[1].Scope != ScopeProject || p.Add[1].Path != ProjectNeoPath(cfg.RootDir) {
+		t.Fatalf("expected classified project fact, got %#v", p.Add[1])
+	}
+	if len(p.Remove) != 1 || p.Remove[0].Scope != ScopeUser || p.Remove[0].Path != resolveNeoPath(cfg) {
+		t.Fatalf("expected 1 user removal, got %#v", p.Remove)
+	}
+}
+
+func TestAcceptPendingPromotion(t *testing.T) {
+	cfg := promoteConfig(t)
+	p := normalizePromotion(cfg, Promotion{
+		Add:    []PromotionOp{{Scope: ScopeProject, Fact: "Tests run with make test"}, {Scope: ScopeUser, Fact: "User writes British English"}},
+		Remove: []PromotionOp{{Scope: ScopeUser, Fact: "User prefers concise answers"}},
+	})
+	if err := SavePendingPromotion(cfg.BrainDir, p); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	diff := PromotionDiff(p)
+	for _, want := range []string{"+- Tests run with make test", "-- User prefers concise answers", "+- User writes British English"} {
+		if !strings.Contains(diff, want) {
+			t.Fatalf("expected %q in diff:\n%s", want, diff)
+		}
+	}
+
+	added, removed, err := AcceptPendingPromotion(cfg.BrainDir)
+	if err != nil || added != 2 || removed != 1 {
+		t.Fatalf("expected +2 -1, got +%d -%d (%v)", added, removed, err)
+	}
+	b, _ := os.ReadFile(resolveNeoPath(cfg))
+	if string(b) != "# Long-Term Memory (NEO)\n\n- User writes British English\n" {
+		t.Fatalf("unexpected user NEO: %q", string(b))
+	}
+	b, _ = os.ReadFile(ProjectNeoPath(cfg.RootDir))
+	if string(b) != "# Project Memory (NEO)\n\n- Tests run with make test\n" {
+		t.Fatalf("unexpected project NEO: %q", string(b))
+	}
+	if pending, _ := LoadPendingPromotion(cfg.BrainDir); !pending.Empty() {
+		t.Fatal("expected pending promotion to be cleared")
+	}
+	if _, _, err := AcceptPendingPromotion(cfg.BrainDir); err == nil {
+		t.Fatal("expected error with nothing pending")
+	}
+}
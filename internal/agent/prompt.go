@@ -2,7 +2,7 @@ package agent
 
 import "strings"
 
-func BuildDeveloperMessage(agentConfig, soul, neo, stmContext, convContext, prompt string, refs []FileRef, fileList []string, listTruncated bool) string {
+func BuildDeveloperMessage(agentConfig, soul, neo, projectNeo, stmContext, convContext, prompt string, refs []FileRef, fileList []string, listTruncated bool) string {
 	var b strings.Builder
 	b.WriteString("You are minibrain, a minimal agentic loop runner.\n")
 	b.WriteString("Stay concise and explicit.\n\n")
@@ -21,13 +21,20 @@ func BuildDeveloperMessage(agentConfig, soul, neo, stmContext, convContext, prom
 		b.WriteString(soul + "\n\n")
 	}
 
-	b.WriteString("Long-term memory (cortex/NEO.md):\n")
+	b.WriteString("Long-term memory, user-wide (relevant entries from cortex/NEO.md):\n")
 	if strings.TrimSpace(neo) == "" {
 		b.WriteString("(empty)\n\n")
 	} else {
 		b.WriteString(neo + "\n\n")
 	}
 
+	b.WriteString("Long-term memory, this project (relevant entries from .minibrain/NEO.md):\n")
+	if strings.TrimSpace(projectNeo) == "" {
+		b.WriteString("(empty)\n\n")
+	} else {
+		b.WriteString(projectNeo + "\n\n")
+	}
+
 	b.WriteString("Short-term memory context (recent PREFRONTAL.md):\n")
 	if strings.TrimSpace(stmContext) == "" {
 		b.WriteString("(empty)\n\n")
@@ -82,11 +89,12 @@ func BuildDeveloperMessage(agentConfig, soul, neo, stmContext, convContext, prom
 	b.WriteString("Use these fields:\n")
 	b.WriteString("- read: list of file paths you need to read\n")
 	b.WriteString("- patches: list of {path, diff} with unified diffs including @@ -a,b +c,d @@ hunks\n")
+	b.WriteString("- edits: list of {path, old_string, new_string, replace_all}; old_string must match the file exactly and be unique unless replace_all is true\n")
 	b.WriteString("- writes: list of {path, content} for full-file rewrites or new files\n")
 	b.WriteString("- deletes: list of paths to delete\n")
 	b.WriteString("- message: short user-facing summary\n\n")
-	b.WriteString("If you need file contents, populate read[] and leave patches/writes/deletes empty.\n")
+	b.WriteString("If you need file contents, populate read[] and leave patches/edits/writes/deletes empty.\n")
 	b.WriteString("Never assume file contents from filenames alone.\n")
-	b.WriteString("Prefer patches for edits, writes for full replacements.\n")
+	b.WriteString("Prefer edits for small targeted changes, patches for larger edits, writes for full replacements or new files.\n")
 	return b.String()
 }
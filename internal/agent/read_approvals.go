@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// ReadApprovals are the user's answers to read prompts, as paths or globs
+// matched like path rules. The TUI keeps session answers in memory and
+// saves permanent ones to .minibrain/config.json. A denial wins.
+type ReadApprovals struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Decide reports whether rel is allowed, and whether any approval covers it
+// at all.
+func (a ReadApprovals) Decide(rel string) (allowed, decided bool) {
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	for _, p := range a.Deny {
+		if ok, err := matchRule(p, rel); ok || err != nil {
+			return false, true
+		}
+	}
+	for _, p := range a.Allow {
+		if ok, _ := matchRule(p, rel); ok {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// Add records a decision for pattern, replacing an earlier opposite one.
+func (a *ReadApprovals) Add(pattern string, allow bool) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	a.Allow = slices.DeleteFunc(a.Allow, func(p string) bool { return p == pattern })
+	a.Deny = slices.DeleteFunc(a.Deny, func(p string) bool { return p == pattern })
+	if allow {
+		a.Allow = append(a.Allow, pattern)
+	} else {
+		a.Deny = append(a.Deny, pattern)
+	}
+}
+
+// Merge returns the union of both sets of approvals.
+func (a ReadApprovals) Merge(b ReadApprovals) ReadApprovals {
+	return ReadApprovals{
+		Allow: slices.Concat(a.Allow, b.Allow),
+		Deny:  slices.Concat(a.Deny, b.Deny),
+	}
+}
+
+// approveRead is the read check used for mentions: a per-path denial wins,
+// then a session-wide allow or a per-path approval lets the read through.
+func approveRead(cfg Config, approvals ReadApprovals) func(string) error {
+	return func(rel string) error {
+		allowed, decided := approvals.Decide(rel)
+		if decided && !allowed {
+			return errors.New("permission denied: read of " + rel + " was denied")
+		}
+		if cfg.AllowReadAll || allowed {
+			return nil
+		}
+		return errors.New("permission denied: reading file content requires approval")
+	}
+}
+
+// readApprovalSource names what let rel be read, for the audit log: a saved
+// answer, a session answer, or whatever allowed reading everything.
+func readApprovalSource(cfg Config, saved ReadApprovals) func(string) string {
+	return func(rel string) string {
+		if ok, _ := saved.Decide(rel); ok {
+			return "/always"
+		}
+		if ok, _ := cfg.ReadApprovals.Decide(rel); ok {
+			return "/yes"
+		}
+		return cfg.ReadApproval
+	}
+}
+
+// ReadCandidate is a file a prompt or the model wants to read, described
+// for a read prompt before anything is loaded.
+type ReadCandidate struct {
+	Mention string
+	Path    string
+	Size    int64
+	Git     bool
+	Err     error
+}
+
+// DescribeReads resolves mentions or requested paths to the files they
+// would read, with their sizes. Files that cannot be read anyway, because
+// they are missing or blocked by path rules, carry an error.
+func DescribeReads(root string, mentions []string) []ReadCandidate {
+	rules, rulesErr := LoadPathRules(root)
+	seen := map[string]struct{}{}
+	var out []ReadCandidate
+	for _, m := range mentions {
+		c := ReadCandidate{Mention: m, Path: m}
+		if rulesErr != nil {
+			c.Err = rulesErr
+		} else if isGitMention(root, m) {
+			c.Git = true
+		} else if resolved, ok := resolveMention(root, m); !ok {
+			c.Err = errors.New("not found")
+		} else if clean, err := safeRelPath(resolved); err != nil {
+			c.Err = err
+		} else {
+			c.Path = filepath.ToSlash(clean)
+			if err := rules.CheckRead(clean); err != nil {
+				c.Err = err
+			} else if info, err := os.Stat(filepath.Join(root, clean)); err != nil {
+				c.Err = err
+			} else {
+				c.Size = info.Size()
+			}
+		}
+		if _, ok := seen[c.Path]; ok {
+			continue
+		}
+		seen[c.Path] = struct{}{}
+		out = append(out, c)
+	}
+	return out
+}
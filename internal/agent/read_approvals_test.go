@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadApprovalsDecide(t *testing.T) {
+	var a ReadApprovals
+	a.Add("docs/**", true)
+	a.Add("docs/private.md", false)
+	if ok, decided := a.Decide("docs/guide.md"); !ok || !decided {
+		t.Fatal("expected the glob to allow docs/guide.md")
+	}
+	if ok, decided := a.Decide("docs/private.md"); ok || !decided {
+		t.Fatal("expected the denial to win")
+	}
+	if _, decided := a.Decide("main.go"); decided {
+		t.Fatal("expected main.go to be undecided")
+	}
+	a.Add("docs/private.md", true)
+	if ok, _ := a.Decide("docs/private.md"); !ok || len(a.Deny) != 0 {
+		t.Fatalf("expected the new answer to replace the old one, got %+v", a)
+	}
+}
+
+func TestDescribeReads(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "hello", ".env": "TOKEN=x\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SaveProjectConfig(root, ProjectConfig{Permissions: PathRules{Read: RuleSet{Deny: []string{".env"}}}}); err != nil {
+		t.Fatal(err)
+	}
+	got := DescribeReads(root, []string{"a.txt", "./a.txt", ".env", "diff"})
+	if len(got) != 3 {
+		t.Fatalf("expected duplicates merged, got %+v", got)
+	}
+	if got[0].Path != "a.txt" || got[0].Size != 5 || got[0].Err != nil {
+		t.Fatalf("unexpected candidate %+v", got[0])
+	}
+	if got[1].Err == nil {
+		t.Fatal("expected the rule-blocked file to carry an error")
+	}
+	if !got[2].Git || got[2].Err != nil {
+		t.Fatalf("expected a git candidate, got %+v", got[2])
+	}
+}
+
+func TestApproveReadPerFile(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	approvals := ReadApprovals{Allow: []string{"a.txt"}, Deny: []string{"c.txt"}}
+	refs := loadFileRefs(root, []string{"a.txt", "b.txt"}, approveRead(Config{}, approvals), 0, 0)
+	if refs[0].Err != nil || refs[1].Err == nil {
+		t.Fatalf("expected only a.txt to load, got %+v", refs)
+	}
+	refs = loadFileRefs(root, []string{"b.txt", "c.txt"}, approveRead(Config{AllowReadAll: true}, approvals), 0, 0)
+	if refs[0].Err != nil || refs[1].Err == nil {
+		t.Fatalf("expected the denial to win over allowing everything, got %+v", refs)
+	}
+}
@@ -0,0 +1,247 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Redaction is one secret replaced before text left the process or reached
+// the brain dir. Only a fingerprint of the secret is kept.
+type Redaction struct {
+	Time        string `json:"time"`
+	Source      string `json:"source"`
+	Kind        string `json:"kind"`
+	Fingerprint string `json:"fingerprint"`
+	Length      int    `json:"length"`
+
+	secret      string
+	placeholder string
+}
+
+type secretDetector struct {
+	kind string
+	re   *regexp.Regexp
+	// check, when set, filters candidate matches (used for entropy).
+	check func(string) bool
+}
+
+// Redactor replaces secrets found by the built-in detectors and any custom
+// patterns. A custom pattern with a capture group redacts only the group.
+type Redactor struct {
+	detectors []secretDetector
+}
+
+var builtinDetectors = []secretDetector{
+	{kind: "private-key", re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{kind: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{kind: "aws-secret-key", re: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{kind: "gcp-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{kind: "openai-key", re: regexp.MustCompile(`\bsk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_\-]{20,}`)},
+	{kind: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`)},
+	{
+		kind:  "secret-assignment",
+		re:    regexp.MustCompile(`(?i)(?:api_?key|secret|token|passw(?:or)?d|pwd|credential|auth)[A-Za-z0-9_]*["']?\s*[:=]\s*["']?([A-Za-z0-9+/=_\-.]{12,})`),
+		check: func(s string) bool { return shannonEntropy(s) >= 3.0 && hasDigitAndLetter(s) },
+	},
+	{
+		kind:  "high-entropy",
+		re:    regexp.MustCompile(`[A-Za-z0-9+/=_\-]{32,}`),
+		check: func(s string) bool { return shannonEntropy(s) >= 4.3 && hasMixedCase(s) && hasDigitAndLetter(s) },
+	},
+}
+
+// NewRedactor builds a redactor with the built-in detectors and patterns.
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{detectors: append([]secretDetector{}, builtinDetectors...)}
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.detectors = append(r.detectors, secretDetector{kind: "custom", re: re})
+	}
+	return r, nil
+}
+
+// Redact returns text with every secret replaced by a placeholder naming its
+// kind and fingerprint. The same secret always gets the same placeholder.
+func (r *Redactor) Redact(source, text string) (string, []Redaction) {
+	var found []Redaction
+	for _, d := range r.detectors {
+		text = d.re.ReplaceAllStringFunc(text, func(match string) string {
+			secret, prefix, suffix := match, "", ""
+			if d.re.NumSubexp() > 0 {
+				loc := d.re.FindStringSubmatchIndex(match)
+				if len(loc) >= 4 && loc[2] >= 0 {
+					prefix, secret, suffix = match[:loc[2]], match[loc[2]:loc[3]], match[loc[3]:]
+				}
+			}
+			if strings.HasPrefix(secret, "[REDACTED:") || (d.check != nil && !d.check(secret)) {
+				return match
+			}
+			red := newRedaction(source, d.kind, secret)
+			found = append(found, red)
+			return prefix + red.placeholder + suffix
+		})
+	}
+	return text, found
+}
+
+func newRedaction(source, kind, secret string) Redaction {
+	sum := sha256.Sum256([]byte(secret))
+	fp := hex.EncodeToString(sum[:4])
+	return Redaction{
+		Time:        time.Now().UTC().Format(time.RFC3339),
+		Source:      source,
+		Kind:        kind,
+		Fingerprint: fp,
+		Length:      len(secret),
+		secret:      secret,
+		placeholder: "[REDACTED:" + kind + ":" + fp + "]",
+	}
+}
+
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+func hasDigitAndLetter(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0 && strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+func hasMixedCase(s string) bool {
+	return strings.IndexFunc(s, unicode.IsUpper) >= 0 && strings.IndexFunc(s, unicode.IsLower) >= 0
+}
+
+var redactors sync.Map
+
+// redactorFor returns the redactor for cfg's custom patterns. Invalid
+// patterns are reported when the config is built, so here they are dropped.
+func redactorFor(cfg Config) *Redactor {
+	key := strings.Join(cfg.RedactPatterns, "\x00")
+	if r, ok := redactors.Load(key); ok {
+		return r.(*Redactor)
+	}
+	var valid []string
+	for _, p := range cfg.RedactPatterns {
+		if _, err := regexp.Compile(p); err == nil {
+			valid = append(valid, p)
+		}
+	}
+	r, _ := NewRedactor(valid)
+	redactors.Store(key, r)
+	return r
+}
+
+func RedactionLogPath(brainDir string) string {
+	return filepath.Join(brainDir, "cortex", "redactions.jsonl")
+}
+
+// redactText redacts text for source and logs what was found under brainDir.
+func redactText(cfg Config, brainDir, source, text string) string {
+	out, found := redactorFor(cfg).Redact(source, text)
+	logRedactions(brainDir, found)
+	return out
+}
+
+// redactFileRefs redacts loaded file contents. Each ref remembers its
+// placeholders so that changes to the file can put the secrets back.
+func redactFileRefs(cfg Config, brainDir string, refs []FileRef) []FileRef {
+	out := make([]FileRef, len(refs))
+	for i, ref := range refs {
+		if ref.Err == nil && ref.Content != "" {
+			var found []Redaction
+			ref.Content, found = redactorFor(cfg).Redact("file "+ref.Path, ref.Content)
+			for _, red := range found {
+				if ref.secrets == nil {
+					ref.secrets = map[string]string{}
+				}
+				ref.secrets[red.placeholder] = red.secret
+			}
+			logRedactions(brainDir, found)
+		}
+		out[i] = ref
+	}
+	return out
+}
+
+func logRedactions(brainDir string, found []Redaction) {
+	if brainDir == "" || len(found) == 0 {
+		return
+	}
+	var b strings.Builder
+	for _, red := range found {
+		line, err := json.Marshal(red)
+		if err != nil {
+			continue
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	_ = appendLocked(RedactionLogPath(brainDir), []byte(b.String()))
+}
+
+// restoreSecrets puts back secrets the model saw as placeholders, so writes
+// based on redacted reads do not replace real values with placeholders.
+func restoreSecrets(set ChangeSet) ChangeSet {
+	secrets := map[string]string{}
+	for _, ref := range set.Reads {
+		for k, v := range ref.secrets {
+			secrets[k] = v
+		}
+	}
+	if len(secrets) == 0 {
+		return set
+	}
+	pairs := make([]string, 0, len(secrets)*2)
+	for k, v := range secrets {
+		pairs = append(pairs, k, v)
+	}
+	rep := strings.NewReplacer(pairs...)
+	out := set
+	out.Writes = append([]WriteOp(nil), set.Writes...)
+	for i := range out.Writes {
+		out.Writes[i].Content = rep.Replace(out.Writes[i].Content)
+	}
+	out.Patches = append([]PatchOp(nil), set.Patches...)
+	for i := range out.Patches {
+		out.Patches[i].Patch = rep.Replace(out.Patches[i].Patch)
+	}
+	out.Edits = append([]EditOp(nil), set.Edits...)
+	for i := range out.Edits {
+		out.Edits[i].OldString = rep.Replace(out.Edits[i].OldString)
+		out.Edits[i].NewString = rep.Replace(out.Edits[i].NewString)
+	}
+	out.Reads = append([]FileRef(nil), set.Reads...)
+	for i := range out.Reads {
+		if len(out.Reads[i].secrets) > 0 {
+			out.Reads[i].Content = rep.Replace(out.Reads[i].Content)
+		}
+	}
+	return out
+}
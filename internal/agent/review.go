@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	HunkPending  = "pending"
+	HunkAccepted = "accepted"
+	HunkRejected = "rejected"
+	HunkEdited   = "edited"
+)
+
+type ReviewHunk struct {
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+	Lines    []string
+	Status   string
+}
+
+type ReviewItem struct {
+	Kind    string
+	Path    string
+	Hunks   []ReviewHunk
+	content string
+}
+
+func (h ReviewHunk) Header() string {
+	return formatHunkHeader(hunk{oldStart: h.OldStart, oldCount: h.OldCount, newStart: h.NewStart, newCount: h.NewCount})
+}
+
+// NewSide returns the hunk as it reads after the change, which is what the
+// user edits.
+func (h ReviewHunk) NewSide() string {
+	var out []string
+	for _, l := range h.Lines {
+		if strings.HasPrefix(l, " ") || strings.HasPrefix(l, "+") {
+			out = append(out, l[1:])
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// EditHunk replaces the new side of h with edited, keeping the old side so
+// the hunk still anchors against the original file.
+func EditHunk(h ReviewHunk, edited string) ReviewHunk {
+	var lines []string
+	for _, l := range h.Lines {
+		if strings.HasPrefix(l, " ") || strings.HasPrefix(l, "-") {
+			lines = append(lines, "-"+l[1:])
+		}
+	}
+	newLines := splitLines(edited)
+	for _, l := range newLines {
+		lines = append(lines, "+"+l)
+	}
+	h.Lines = lines
+	h.NewCount = len(newLines)
+	h.Status = HunkEdited
+	return h
+}
+
+func BuildReview(root string, writes []WriteOp, deletes []DeleteOp, patches []PatchOp) []ReviewItem {
+	var items []ReviewItem
+	for _, p := range patches {
+		item := ReviewItem{Kind: "PATCH", Path: p.Path}
+		for _, h := range parseHunks(p.Patch) {
+			item.Hunks = append(item.Hunks, reviewHunk(h))
+		}
+		items = append(items, item)
+	}
+	for _, w := range writes {
+		old := ""
+		if clean, err := safeRelPath(w.Path); err == nil {
+			old, _ = readTextOrEmpty(filepath.Join(root, clean))
+		}
+		item := ReviewItem{Kind: "WRITE", Path: w.Path, content: w.Content}
+		for _, h := range buildHunks(diffLines(splitLines(old), splitLines(w.Content)), 3) {
+			item.Hunks = append(item.Hunks, reviewHunk(h))
+		}
+		items = append(items, item)
+	}
+	for _, d := range deletes {
+		items = append(items, ReviewItem{
+			Kind:  "DELETE",
+			Path:  d.Path,
+			Hunks: []ReviewHunk{{Lines: []string{"(delete file)"}, Status: HunkPending}},
+		})
+	}
+	return items
+}
+
+func reviewHunk(h hunk) ReviewHunk {
+	return ReviewHunk{
+		OldStart: h.oldStart,
+		OldCount: h.oldCount,
+		NewStart: h.newStart,
+		NewCount: h.newCount,
+		Lines:    h.lines,
+		Status:   HunkPending,
+	}
+}
+
+// ReviewedChanges turns reviewed items back into ops, keeping only accepted
+// and edited hunks. Writes are re-derived from the file on disk.
+func ReviewedChanges(root string, items []ReviewItem) ([]WriteOp, []DeleteOp, []PatchOp) {
+	var writes []WriteOp
+	var deletes []DeleteOp
+	var patches []PatchOp
+	for _, item := range items {
+		var kept []ReviewHunk
+		all := true
+		for _, h := range item.Hunks {
+			switch h.Status {
+			case HunkAccepted, HunkEdited:
+				kept = append(kept, h)
+				if h.Status == HunkEdited {
+					all = false
+				}
+			default:
+				all = false
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		switch item.Kind {
+		case "DELETE":
+			deletes = append(deletes, DeleteOp{Path: item.Path})
+		case "PATCH":
+			patches = append(patches, PatchOp{Path: item.Path, Patch: formatReviewHunks(kept)})
+		case "WRITE":
+			if all {
+				writes = append(writes, WriteOp{Path: item.Path, Content: item.content})
+				continue
+			}
+			old := ""
+			if clean, err := safeRelPath(item.Path); err == nil {
+				old, _ = readTextOrEmpty(filepath.Join(root, clean))
+			}
+			updated, ok := applyUnifiedPatch(old, formatReviewHunks(kept))
+			if !ok {
+				continue
+			}
+			if old == "" && strings.HasSuffix(item.content, "\n") && !strings.HasSuffix(updated, "\n") {
+				updated += "\n"
+			}
+			writes = append(writes, WriteOp{Path: item.Path, Content: updated})
+		}
+	}
+	return writes, deletes, patches
+}
+
+func formatReviewHunks(hunks []ReviewHunk) string {
+	var b strings.Builder
+	for _, h := range hunks {
+		b.WriteString(h.Header() + "\n")
+		for _, l := range h.Lines {
+			b.WriteString(l + "\n")
+		}
+	}
+	return b.String()
+}
+
+func FormatReviewSummary(items []ReviewItem) string {
+	var b strings.Builder
+	b.WriteString("\n## Review\n")
+	changed := 0
+	for _, item := range items {
+		for i, h := range item.Hunks {
+			if h.Status == HunkAccepted {
+				continue
+			}
+			status := h.Status
+			if status == HunkPending {
+				status = HunkRejected
+			}
+			changed++
+			detail := h.Header()
+			if item.Kind == "DELETE" {
+				detail = "delete"
+			}
+			b.WriteString(fmt.Sprintf("- %s %s: hunk %d/%d %s (%s)\n", item.Kind, item.Path, i+1, len(item.Hunks), status, detail))
+		}
+	}
+	if changed == 0 {
+		b.WriteString("(all hunks accepted)\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReviewedChangesSubset(t *testing.T) {
+	root := t.TempDir()
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte(old), 0644); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	updated := "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n"
+	items := BuildReview(root, []WriteOp{{Path: "a.txt", Content: updated}}, []DeleteOp{{Path: "b.txt"}}, nil)
+	if len(items) != 2 || len(items[0].Hunks) != 2 {
+		t.Fatalf("unexpected review items: %#v", items)
+	}
+	items[0].Hunks[0].Status = HunkRejected
+	items[0].Hunks[1].Status = HunkAccepted
+	items[1].Hunks[0].Status = HunkRejected
+
+	writes, deletes, patches := ReviewedChanges(root, items)
+	if len(deletes) != 0 || len(patches) != 0 || len(writes) != 1 {
+		t.Fatalf("unexpected ops: %#v %#v %#v", writes, deletes, patches)
+	}
+	if writes[0].Content != "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n" {
+		t.Fatalf("unexpected content: %q", writes[0].Content)
+	}
+	summary := FormatReviewSummary(items)
+	if !strings.Contains(summary, "WRITE a.txt: hunk 1/2 rejected") || !strings.Contains(summary, "DELETE b.txt: hunk 1/1 rejected") {
+		t.Fatalf("unexpected summary:\n%s", summary)
+	}
+}
+
+func TestEditHunk(t *testing.T) {
+	items := BuildReview(t.TempDir(), nil, nil, []PatchOp{{Path: "a.txt", Patch: "@@ -1,2 +1,2 @@\n-hello\n+hello world\n line2"}})
+	h := items[0].Hunks[0]
+	if h.NewSide() != "hello world\nline2" {
+		t.Fatalf("unexpected new side: %q", h.NewSide())
+	}
+	edited := EditHunk(h, "hi there\nline2\n")
+	out, ok := applyUnifiedPatch("hello\nline2\n", formatReviewHunks([]ReviewHunk{edited}))
+	if !ok || out != "hi there\nline2\n" {
+		t.Fatalf("unexpected edit result: %q %v", out, ok)
+	}
+}
@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// Session groups the short-term memory and conversation transcript of one
+// task so that separate projects and tasks do not share PREFRONTAL/CONTEXT.
+type Session struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Root    string `json:"root"`
+	Model   string `json:"model"`
+	Started string `json:"started"`
+	Updated string `json:"updated"`
+}
+
+func SessionsDir(brainDir string) string {
+	return filepath.Join(brainDir, "cortex", "sessions")
+}
+
+func (s Session) Dir(brainDir string) string {
+	return filepath.Join(SessionsDir(brainDir), s.ID)
+}
+
+func (s Session) PrefrontalPath(brainDir string) string {
+	return filepath.Join(s.Dir(brainDir), "PREFRONTAL.md")
+}
+
+func (s Session) ContextPath(brainDir string) string {
+	return filepath.Join(s.Dir(brainDir), "CONTEXT.md")
+}
+
+func NewSession(brainDir, root, model string) (Session, error) {
+	base := SessionsDir(brainDir)
+	if err := ensurePrivateDir(base); err != nil {
+		return Session{}, err
+	}
+	now := time.Now().UTC()
+	id := now.Format("20060102-150405")
+	for n := 1; fileExists(filepath.Join(base, id)); n++ {
+		id = fmt.Sprintf("%s-%d", now.Format("20060102-150405"), n)
+	}
+	s := Session{
+		ID:      id,
+		Root:    root,
+		Model:   model,
+		Started: now.Format(time.RFC3339),
+		Updated: now.Format(time.RFC3339),
+	}
+	if err := os.Mkdir(s.Dir(brainDir), 0700); err != nil {
+		return Session{}, err
+	}
+	if err := writeBrainFile(s.PrefrontalPath(brainDir), defaultPrefrontal(), "init"); err != nil {
+		return Session{}, err
+	}
+	if err := writeBrainFile(s.ContextPath(brainDir), defaultContext(), "init"); err != nil {
+		return Session{}, err
+	}
+	return s, SaveSession(brainDir, s)
+}
+
+func SaveSession(brainDir string, s Session) error {
+	if err := validSessionID(s.ID); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(filepath.Join(s.Dir(brainDir), "session.json"), b, 0600)
+}
+
+func LoadSession(brainDir, id string) (Session, error) {
+	if err := validSessionID(id); err != nil {
+		return Session{}, err
+	}
+	b, err := vault.ReadFile(filepath.Join(SessionsDir(brainDir), id, "session.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Session{}, fmt.Errorf("session %s not found", id)
+		}
+		return Session{}, err
+	}
+	var s Session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Session{}, fmt.Errorf("session %s is corrupt: %w", id, err)
+	}
+	s.ID = id
+	return s, nil
+}
+
+// ListSessions returns all sessions, oldest first.
+func ListSessions(brainDir string) ([]Session, error) {
+	entries, err := os.ReadDir(SessionsDir(brainDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []Session
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		s, err := LoadSession(brainDir, e.Name())
+		if err != nil {
+			continue
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Started == out[j].Started {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].Started < out[j].Started
+	})
+	return out, nil
+}
+
+// LatestSession returns the most recently used session for root.
+func LatestSession(brainDir, root string) (Session, bool) {
+	sessions, err := ListSessions(brainDir)
+	if err != nil {
+		return Session{}, false
+	}
+	var best Session
+	found := false
+	for _, s := range sessions {
+		if s.Root != root {
+			continue
+		}
+		if !found || s.Updated > best.Updated || (s.Updated == best.Updated && s.ID > best.ID) {
+			best = s
+			found = true
+		}
+	}
+	return best, found
+}
+
+// TouchSession marks the session as used now and titles it after the first
+// prompt.
+func TouchSession(brainDir, id, prompt string) (Session, error) {
+	if err := validSessionID(id); err != nil {
+		return Session{}, err
+	}
+	var s Session
+	err := withFileLock(filepath.Join(SessionsDir(brainDir), id, "session.json"), func() error {
+		var err error
+		if s, err = LoadSession(brainDir, id); err != nil {
+			return err
+		}
+		if s.Title == "" {
+			s.Title = sessionTitle(prompt)
+		}
+		s.Updated = time.Now().UTC().Format(time.RFC3339)
+		return SaveSession(brainDir, s)
+	})
+	return s, err
+}
+
+func sessionTitle(prompt string) string {
+	title := strings.Join(strings.Fields(prompt), " ")
+	if len(title) > 60 {
+		title = strings.TrimSpace(title[:60]) + "..."
+	}
+	return title
+}
+
+func FormatSession(s Session) string {
+	title := s.Title
+	if title == "" {
+		title = "(untitled)"
+	}
+	line := s.ID + "  " + title
+	var meta []string
+	if s.Root != "" {
+		meta = append(meta, s.Root)
+	}
+	if s.Model != "" {
+		meta = append(meta, s.Model)
+	}
+	if s.Updated != "" {
+		meta = append(meta, "updated "+s.Updated)
+	}
+	if len(meta) > 0 {
+		line += " (" + strings.Join(meta, ", ") + ")"
+	}
+	return line
+}
+
+func validSessionID(id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return errors.New("invalid session id")
+	}
+	return nil
+}
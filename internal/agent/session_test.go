@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewSessionSeedsFiles(t *testing.T) {
+	brain := t.TempDir()
+	a, err := NewSession(brain, "/proj", "gpt-4.1")
+	if err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	b, err := NewSession(brain, "/proj", "gpt-4.1")
+	if err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	if a.ID == b.ID {
+		t.Fatalf("expected unique ids, got %q twice", a.ID)
+	}
+	for _, p := range []string{a.PrefrontalPath(brain), a.ContextPath(brain)} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("expected %s to exist: %v", p, err)
+		}
+	}
+	sessions, err := ListSessions(brain)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d (%v)", len(sessions), err)
+	}
+}
+
+func TestLatestSessionAndTouch(t *testing.T) {
+	brain := t.TempDir()
+	a, _ := NewSession(brain, "/proj", "")
+	b, _ := NewSession(brain, "/proj", "")
+	other, _ := NewSession(brain, "/other", "")
+
+	a.Updated = "2099-01-01T00:00:00Z"
+	if err := SaveSession(brain, a); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if s, ok := LatestSession(brain, "/proj"); !ok || s.ID != a.ID {
+		t.Fatalf("expected latest %s, got %s (%v)", a.ID, s.ID, ok)
+	}
+	if s, ok := LatestSession(brain, "/other"); !ok || s.ID != other.ID {
+		t.Fatalf("expected latest %s for other root, got %s", other.ID, s.ID)
+	}
+
+	s, err := TouchSession(brain, b.ID, "  fix the\nlogin bug  ")
+	if err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+	if s.Title != "fix the login bug" {
+		t.Fatalf("unexpected title %q", s.Title)
+	}
+	s, _ = TouchSession(brain, b.ID, "something else")
+	if s.Title != "fix the login bug" {
+		t.Fatalf("title should not change, got %q", s.Title)
+	}
+}
+
+func TestLoadSessionErrors(t *testing.T) {
+	brain := t.TempDir()
+	if _, err := LoadSession(brain, "../escape"); err == nil {
+		t.Fatal("expected invalid id error")
+	}
+	if _, err := LoadSession(brain, "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
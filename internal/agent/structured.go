@@ -15,9 +15,17 @@ type StructuredWrite struct {
 	Content string `json:"content"`
 }
 
+type StructuredEdit struct {
+	Path       string `json:"path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
+}
+
 type StructuredResponse struct {
 	Read    []string          `json:"read"`
 	Patches []StructuredPatch `json:"patches"`
+	Edits   []StructuredEdit  `json:"edits"`
 	Writes  []StructuredWrite `json:"writes"`
 	Deletes []string          `json:"deletes"`
 	Message string            `json:"message"`
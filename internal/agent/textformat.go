@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"bytes"
+	"os"
+	"strings"
+)
+
+const utf8BOM = "\ufeff"
+
+// textFormat records the byte-level conventions of an existing file so that
+// content produced with plain "\n" line endings can be written back in the
+// same shape.
+type textFormat struct {
+	crlf            bool
+	bom             bool
+	trailingNewline bool
+	known           bool
+}
+
+func detectTextFormat(b []byte) textFormat {
+	if len(b) == 0 {
+		return textFormat{}
+	}
+	f := textFormat{known: true}
+	f.bom = bytes.HasPrefix(b, []byte(utf8BOM))
+	lf := bytes.Count(b, []byte("\n"))
+	crlf := bytes.Count(b, []byte("\r\n"))
+	// Mixed endings are left alone rather than guessed at.
+	f.crlf = crlf > 0 && crlf == lf
+	f.trailingNewline = bytes.HasSuffix(b, []byte("\n"))
+	return f
+}
+
+// decode returns s with the BOM stripped and, for CRLF files, "\n" endings.
+func (f textFormat) decode(s string) string {
+	s = strings.TrimPrefix(s, utf8BOM)
+	if f.crlf {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+	}
+	return s
+}
+
+// encode converts content back to the file's conventions and describes each
+// change it had to make to the content as given.
+func (f textFormat) encode(content string) ([]byte, []string) {
+	if !f.known {
+		return []byte(content), nil
+	}
+	var notes []string
+	if strings.HasPrefix(content, utf8BOM) {
+		content = strings.TrimPrefix(content, utf8BOM)
+		if !f.bom {
+			notes = append(notes, "removed UTF-8 BOM")
+		}
+	} else if f.bom {
+		notes = append(notes, "kept UTF-8 BOM")
+	}
+	hasCRLF := strings.Contains(content, "\r\n")
+	if hasCRLF && !f.crlf && strings.Count(content, "\r\n") == strings.Count(content, "\n") {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+		notes = append(notes, "converted CRLF line endings to LF")
+	}
+	if f.crlf {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	if content != "" {
+		switch {
+		case f.trailingNewline && !strings.HasSuffix(content, "\n"):
+			content += "\n"
+			notes = append(notes, "restored trailing newline")
+		case !f.trailingNewline && strings.HasSuffix(content, "\n"):
+			content = strings.TrimSuffix(content, "\n")
+			notes = append(notes, "removed trailing newline")
+		}
+	}
+	if f.crlf {
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+		if !hasCRLF && strings.Contains(content, "\r\n") {
+			notes = append(notes, "kept CRLF line endings")
+		}
+	}
+	if f.bom {
+		content = utf8BOM + content
+	}
+	return []byte(content), notes
+}
+
+// readTextOrEmpty reads path for diffing and editing, with line endings and
+// BOM normalized the same way the apply path does.
+func readTextOrEmpty(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return detectTextFormat(b).decode(string(b)), nil
+}
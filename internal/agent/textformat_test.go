@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyChangeSetPreservesFormat(t *testing.T) {
+	root := t.TempDir()
+	script := filepath.Join(root, "run.sh")
+	if err := os.WriteFile(script, []byte("\ufeff#!/bin/sh\r\necho hi\r\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{Patches: []PatchOp{{
+		Path:  "run.sh",
+		Patch: "@@ -1,2 +1,2 @@\n #!/bin/sh\n-echo hi\n+echo bye\n",
+	}}})
+	if !report.Committed {
+		t.Fatalf("expected patch to apply: %+v", report.Results)
+	}
+	b, _ := os.ReadFile(script)
+	if string(b) != "\ufeff#!/bin/sh\r\necho bye\r\n" {
+		t.Fatalf("unexpected content: %q", b)
+	}
+	info, _ := os.Stat(script)
+	if info.Mode().Perm() != 0755 {
+		t.Fatalf("mode not preserved: %v", info.Mode())
+	}
+	if n := report.Results[0].Normalized; !strings.Contains(n, "kept CRLF") || !strings.Contains(n, "kept UTF-8 BOM") {
+		t.Fatalf("unexpected normalization note: %q", n)
+	}
+}
+
+func TestApplyChangeSetRestoresTrailingNewline(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "b.txt"), []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report := ApplyChangeSet(root, ChangeSet{Writes: []WriteOp{
+		{Path: "a.txt", Content: "two"},
+		{Path: "b.txt", Content: "two\n"},
+		{Path: "c.txt", Content: "new"},
+	}})
+	if !report.Committed {
+		t.Fatalf("expected writes to apply: %+v", report.Results)
+	}
+	for path, want := range map[string]string{"a.txt": "two\n", "b.txt": "two", "c.txt": "new"} {
+		b, _ := os.ReadFile(filepath.Join(root, path))
+		if string(b) != want {
+			t.Fatalf("%s: unexpected content %q", path, b)
+		}
+	}
+	summary := FormatNormalizedSummary(report)
+	if !strings.Contains(summary, "a.txt: restored trailing newline") || strings.Contains(summary, "c.txt") {
+		t.Fatalf("unexpected summary: %q", summary)
+	}
+}
+
+func TestTextFormatMixedEndings(t *testing.T) {
+	f := detectTextFormat([]byte("a\r\nb\n"))
+	if f.crlf {
+		t.Fatal("mixed endings should not be treated as CRLF")
+	}
+	if got := f.decode("a\r\nb\n"); got != "a\r\nb\n" {
+		t.Fatalf("mixed content should be left as is: %q", got)
+	}
+}
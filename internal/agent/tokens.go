@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"strings"
+	"unicode"
+)
+
+// EstimateTokens approximates how many tokens s costs: about four characters
+// per token within a word, and one token per punctuation mark.
+func EstimateTokens(s string) int {
+	tokens, run := 0, 0
+	flush := func() {
+		tokens += (run + 3) / 4
+		run = 0
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			run++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// tailTokens returns the whole lines at the end of s that fit in maxTokens.
+func tailTokens(s string, maxTokens int) string {
+	if EstimateTokens(s) <= maxTokens {
+		return s
+	}
+	lines := strings.SplitAfter(s, "\n")
+	used, start := 0, len(lines)
+	for start > 0 {
+		n := EstimateTokens(lines[start-1])
+		if used+n > maxTokens {
+			break
+		}
+		used += n
+		start--
+	}
+	return strings.Join(lines[start:], "")
+}
+
+// headTokens returns the start of s, cut at a space, that fits in maxTokens.
+func headTokens(s string, maxTokens int) string {
+	used, end := 0, 0
+	for _, word := range strings.SplitAfter(s, " ") {
+		n := EstimateTokens(word)
+		if used+n > maxTokens {
+			break
+		}
+		used += n
+		end += len(word)
+	}
+	return strings.TrimSpace(s[:end])
+}
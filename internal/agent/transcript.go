@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/llm"
+	"github.com/chrishannah/minibrain/internal/vault"
+)
+
+// The transcript is the structured record of a session: one JSON event per
+// line. PREFRONTAL.md and CONTEXT.md are views derived from the same turns
+// for the model and for people to read.
+const (
+	EventTurn  = "turn"
+	EventApply = "apply"
+)
+
+type TranscriptEvent struct {
+	Time          string              `json:"time"`
+	Type          string              `json:"type"`
+	Session       string              `json:"session,omitempty"`
+	Model         string              `json:"model,omitempty"`
+	Prompt        string              `json:"prompt,omitempty"`
+	Mentions      []string            `json:"mentions,omitempty"`
+	Files         []TranscriptFile    `json:"files,omitempty"`
+	DeveloperHash string              `json:"developer_sha256,omitempty"`
+	RawOutput     string              `json:"raw_output,omitempty"`
+	Response      *StructuredResponse `json:"response,omitempty"`
+	Ops           []TranscriptOp      `json:"ops,omitempty"`
+	Usage         *llm.Usage          `json:"usage,omitempty"`
+	Timings       TranscriptTimings   `json:"timings"`
+	Error         string              `json:"error,omitempty"`
+}
+
+type TranscriptFile struct {
+	Path    string `json:"path"`
+	Mention string `json:"mention,omitempty"`
+	Hash    string `json:"sha256,omitempty"`
+	Bytes   int    `json:"bytes"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TranscriptOp is one proposed or applied change. Status is "proposed" for
+// changes not yet applied, otherwise an Op* status. Applied changes keep the
+// redacted diff of the file as it was before and after.
+type TranscriptOp struct {
+	Kind   string `json:"kind"`
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+const maxTranscriptDiffLen = 20000
+
+type TranscriptTimings struct {
+	LoadMs  int64 `json:"load_ms,omitempty"`
+	LLMMs   int64 `json:"llm_ms,omitempty"`
+	ApplyMs int64 `json:"apply_ms,omitempty"`
+	TotalMs int64 `json:"total_ms"`
+}
+
+// TranscriptPath is the transcript kept beside a session's PREFRONTAL.md.
+func TranscriptPath(prefrontalPath string) string {
+	return filepath.Join(filepath.Dir(prefrontalPath), "transcript.jsonl")
+}
+
+func (s Session) TranscriptPath(brainDir string) string {
+	return filepath.Join(s.Dir(brainDir), "transcript.jsonl")
+}
+
+func AppendTranscript(path string, ev TranscriptEvent) error {
+	if ev.Time == "" {
+		ev.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if err := ensurePrivateDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return appendLocked(path, append(line, '\n'))
+}
+
+// LoadTranscript reads a transcript, skipping lines that do not parse.
+func LoadTranscript(path string) ([]TranscriptEvent, error) {
+	b, err := vault.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var events []TranscriptEvent
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var ev TranscriptEvent
+		if json.Unmarshal(scanner.Bytes(), &ev) == nil {
+			events = append(events, ev)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// RecordApply logs changes applied after a turn, such as ones confirmed in
+// the TUI. Diffs are redacted with cfg's patterns.
+func RecordApply(cfg Config, prefrontalPath string, report ChangeReport, elapsed time.Duration) error {
+	ev := TranscriptEvent{Type: EventApply, Ops: reportOps(cfg, report)}
+	ev.Timings.ApplyMs = elapsed.Milliseconds()
+	ev.Timings.TotalMs = ev.Timings.ApplyMs
+	if report.Err != nil {
+		ev.Error = report.Err.Error()
+	}
+	return AppendTranscript(TranscriptPath(prefrontalPath), ev)
+}
+
+func transcriptFiles(refs []FileRef) []TranscriptFile {
+	var out []TranscriptFile
+	for _, r := range refs {
+		f := TranscriptFile{Path: r.Path, Mention: r.Mention, Hash: r.Hash, Bytes: len(r.Content)}
+		if r.Err != nil {
+			f.Error = r.Err.Error()
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func reportOps(cfg Config, report ChangeReport) []TranscriptOp {
+	var out []TranscriptOp
+	for _, res := range report.Results {
+		op := TranscriptOp{Kind: res.Kind, Path: res.Path, Status: res.Status, Reason: res.Reason}
+		if res.Diff != "" {
+			op.Diff, _ = redactorFor(cfg).Redact("transcript diff", res.Diff)
+			if len(op.Diff) > maxTranscriptDiffLen {
+				op.Diff = op.Diff[:maxTranscriptDiffLen] + "\n... (truncated)\n"
+			}
+		}
+		out = append(out, op)
+	}
+	return out
+}
+
+func proposedOps(set ChangeSet) []TranscriptOp {
+	var out []TranscriptOp
+	for _, w := range set.Writes {
+		out = append(out, TranscriptOp{Kind: "WRITE", Path: w.Path, Status: "proposed"})
+	}
+	for _, d := range set.Deletes {
+		out = append(out, TranscriptOp{Kind: "DELETE", Path: d.Path, Status: "proposed"})
+	}
+	for _, p := range set.Patches {
+		out = append(out, TranscriptOp{Kind: "PATCH", Path: p.Path, Status: "proposed"})
+	}
+	for _, e := range set.Edits {
+		out = append(out, TranscriptOp{Kind: "EDIT", Path: e.Path, Status: "proposed"})
+	}
+	return out
+}
+
+// transcriptResponse parses the redacted output for the transcript, so no
+// secret the model echoed is stored.
+func transcriptResponse(redactedOut string) *StructuredResponse {
+	resp, ok := ParseStructuredOutput(redactedOut)
+	if !ok {
+		return nil
+	}
+	return &resp
+}
+
+func usagePtr(u llm.Usage) *llm.Usage {
+	if u == (llm.Usage{}) {
+		return nil
+	}
+	return &u
+}
+
+func sinceMs(t time.Time) int64 {
+	return time.Since(t).Milliseconds()
+}
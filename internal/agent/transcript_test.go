@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrishannah/minibrain/internal/llm"
+)
+
+func TestTranscriptRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	prefrontal := filepath.Join(dir, "sessions", "abc", "PREFRONTAL.md")
+	path := TranscriptPath(prefrontal)
+
+	refs := []FileRef{
+		{Mention: "@main.go", Path: "main.go", Content: "package main\n", Hash: hashContent([]byte("package main\n"))},
+		{Mention: "@missing.go", Path: "missing.go", Err: errors.New("not found")},
+	}
+	turn := TranscriptEvent{
+		Type:     EventTurn,
+		Session:  "abc",
+		Prompt:   "fix @main.go",
+		Mentions: []string{"main.go"},
+		Files:    transcriptFiles(refs),
+		Response: transcriptResponse(`{"read":[],"patches":[],"edits":[],"writes":[{"path":"a.txt","content":"x"}],"deletes":[],"message":"done"}`),
+		Ops:      proposedOps(ChangeSet{Writes: []WriteOp{{Path: "a.txt"}}}),
+		Usage:    usagePtr(llm.Usage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12}),
+	}
+	if err := AppendTranscript(path, turn); err != nil {
+		t.Fatal(err)
+	}
+	report := ChangeReport{Results: []OpResult{{Kind: "WRITE", Path: "a.txt", Status: OpApplied}}}
+	if err := RecordApply(Config{}, prefrontal, report, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(mustRead(t, path), []byte("not json\n")...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := LoadTranscript(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	got := events[0]
+	if got.Type != EventTurn || got.Time == "" || got.Prompt != "fix @main.go" || got.Usage == nil || got.Usage.TotalTokens != 12 {
+		t.Fatalf("unexpected turn %+v", got)
+	}
+	if len(got.Files) != 2 || got.Files[0].Hash == "" || got.Files[0].Bytes != 13 || got.Files[1].Error != "not found" {
+		t.Fatalf("unexpected files %+v", got.Files)
+	}
+	if got.Response == nil || got.Response.Message != "done" || got.Ops[0].Status != "proposed" {
+		t.Fatalf("unexpected response or ops %+v %+v", got.Response, got.Ops)
+	}
+	if apply := events[1]; apply.Type != EventApply || apply.Ops[0].Status != OpApplied || apply.Timings.ApplyMs != 5 {
+		t.Fatalf("unexpected apply event %+v", apply)
+	}
+	if events, err := LoadTranscript(filepath.Join(dir, "none.jsonl")); err != nil || events != nil {
+		t.Fatalf("expected no events for a missing transcript, got %v %v", events, err)
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(b), "\n") {
+		t.Fatalf("expected newline-terminated JSONL, got %q", b)
+	}
+	return b
+}
@@ -4,12 +4,18 @@ type FileRef struct {
 	Mention string
 	Path    string
 	Content string
+	Hash    string
 	Err     error
+
+	// secrets maps the placeholders redacted from Content to their values.
+	secrets map[string]string
 }
 
 type WriteOp struct {
 	Path    string
 	Content string
+	Added   int
+	Removed int
 }
 
 type DeleteOp struct {
@@ -21,6 +27,13 @@ type PatchOp struct {
 	Patch string
 }
 
+type EditOp struct {
+	Path       string
+	OldString  string
+	NewString  string
+	ReplaceAll bool
+}
+
 type Result struct {
 	LLMOutput         string
 	RawOutput         string
@@ -28,10 +41,13 @@ type Result struct {
 	ProposedWrites    []WriteOp
 	ProposedDeletes   []DeleteOp
 	ProposedPatches   []PatchOp
+	ProposedEdits     []EditOp
 	AppliedWrites     []WriteOp
 	AppliedDeletes    []DeleteOp
 	AppliedPatches    []PatchOp
+	AppliedEdits      []EditOp
 	FailedPatches     []PatchFailure
+	Report            ChangeReport
 	ReadRequests      []string
 	PatchRetryPaths   []string
 	Applied           bool
@@ -42,6 +58,9 @@ type Result struct {
 	FileListTruncated bool
 	Memory            MemoryStats
 	Condensed         bool
+	// AuditErr is set when applied changes could not be written to the
+	// audit log.
+	AuditErr error
 }
 
 type Config struct {
@@ -50,17 +69,28 @@ type Config struct {
 	Model               string
 	TimeoutSec          int
 	NeoPath             string
+	ProjectNeoPath      string
+	AutoPromote         bool
+	MemoryTopK          int
+	RedactPatterns      []string
 	PrefrontalPath      string
-	StmMaxBytes         int
-	StmContextBytes     int
-	ConversationBytes   int
+	ContextPath         string
+	SessionID           string
+	StmMaxTokens        int
+	StmKeepTurns        int
+	StmContextTokens    int
+	ConversationTokens  int
 	ContextBudgetTokens int
 	AllowReadAll        bool
+	ReadApprovals       ReadApprovals
+	ReadApproval        string
+	WriteApproval       string
 	ApplyWrites         bool
 	ReadPaths           []string
 	MaxFilesListed      int
 	MaxFileBytes        int
 	MaxTotalReadBytes   int
+	Git                 GitOptions
 }
 
 type MemoryStats struct {
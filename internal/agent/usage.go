@@ -32,38 +32,39 @@ func GetUsageStats(cfg Config) (UsageStats, error) {
 	if prefrontalPath == "" {
 		prefrontalPath = filepath.Join(brainDir, "cortex", "PREFRONTAL.md")
 	}
-	contextPath := filepath.Join(brainDir, "cortex", "CONTEXT.md")
+	contextPath := cfg.ContextPath
+	if contextPath == "" {
+		contextPath = filepath.Join(brainDir, "cortex", "CONTEXT.md")
+	}
 
 	neo, _ := readFileOrEmpty(neoPath)
+	if p := resolveProjectNeoPath(cfg); p != "" {
+		projectNeo, _ := readFileOrEmpty(p)
+		neo += projectNeo
+	}
 	pre, _ := readFileOrEmpty(prefrontalPath)
 	ctx, _ := readFileOrEmpty(contextPath)
 
-	stmContext := cfg.StmContextBytes
+	stmContext := cfg.StmContextTokens
 	if stmContext <= 0 {
-		stmContext = 4000
+		stmContext = 1000
 	}
-	convContext := cfg.ConversationBytes
+	convContext := cfg.ConversationTokens
 	if convContext <= 0 {
-		convContext = 4000
+		convContext = 1000
 	}
 
-	stmBytes := len(pre)
-	convBytes := len(ctx)
-
-	if stmBytes > stmContext {
-		stmBytes = stmContext
-	}
-	if convBytes > convContext {
-		convBytes = convContext
-	}
+	stmTail := buildShortTermContext(prefrontalPath, stmContext)
+	convTail := loadConversationContext(contextPath, convContext)
+	stmBytes := len(stmTail)
+	convBytes := len(convTail)
 
 	budget := cfg.ContextBudgetTokens
 	if budget <= 0 {
 		budget = 16000
 	}
 
-	totalBytes := len(neo) + stmBytes + convBytes
-	approxTokens := totalBytes / 4
+	approxTokens := EstimateTokens(neo) + EstimateTokens(stmTail) + EstimateTokens(convTail)
 
 	return UsageStats{
 		LtmBytes:         len(neo),
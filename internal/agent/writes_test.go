@@ -28,19 +28,17 @@ func TestParseReadLines(t *testing.T) {
 
 func TestApplyWritesDeletes(t *testing.T) {
 	root := t.TempDir()
-	writes := []WriteOp{{Path: "a.txt", Content: "hi"}}
-	applied := ApplyWrites(root, writes)
-	if len(applied) != 1 {
-		t.Fatalf("expected 1 applied write, got %d", len(applied))
+	report := ApplyChangeSet(root, ChangeSet{Writes: []WriteOp{{Path: "a.txt", Content: "hi"}}})
+	if !report.Committed || len(report.Writes) != 1 {
+		t.Fatalf("expected 1 applied write, got %+v", report)
 	}
 	b, err := os.ReadFile(filepath.Join(root, "a.txt"))
 	if err != nil || string(b) != "hi" {
 		t.Fatalf("read back failed: %v %s", err, string(b))
 	}
-	dels := []DeleteOp{{Path: "a.txt"}}
-	appliedD := ApplyDeletes(root, dels)
-	if len(appliedD) != 1 {
-		t.Fatalf("expected 1 applied delete, got %d", len(appliedD))
+	report = ApplyChangeSet(root, ChangeSet{Deletes: []DeleteOp{{Path: "a.txt"}}})
+	if !report.Committed || len(report.Deletes) != 1 {
+		t.Fatalf("expected 1 applied delete, got %+v", report)
 	}
 	if _, err := os.Stat(filepath.Join(root, "a.txt")); err == nil {
 		t.Fatal("expected file to be deleted")
@@ -58,9 +56,9 @@ func TestParseAndApplyPatch(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello\nline2\n"), 0644); err != nil {
 		t.Fatalf("write seed: %v", err)
 	}
-	applied, _ := ApplyPatches(root, ops)
-	if len(applied) != 1 {
-		t.Fatalf("expected applied patch, got %d", len(applied))
+	report := ApplyChangeSet(root, ChangeSet{Patches: ops})
+	if !report.Committed || len(report.Patches) != 1 {
+		t.Fatalf("expected applied patch, got %+v", report.Results)
 	}
 	b, err := os.ReadFile(filepath.Join(root, "a.txt"))
 	if err != nil {
@@ -0,0 +1,230 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type Repo struct {
+	Dir string
+}
+
+func Available() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// Open returns the repository containing dir.
+func Open(dir string) (Repo, error) {
+	if !Available() {
+		return Repo{}, errors.New("git binary not found in PATH")
+	}
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return Repo{}, errors.New("not a git repository")
+	}
+	return Repo{Dir: strings.TrimSpace(out)}, nil
+}
+
+// Prefix returns the path of dir relative to the top level of its
+// repository, with a trailing slash, or "" at the top level.
+func Prefix(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--show-prefix")
+	return strings.TrimSpace(out), err
+}
+
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return stdout.String(), fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+func (r Repo) run(args ...string) (string, error) {
+	return run(r.Dir, args...)
+}
+
+// DirtyPaths lists paths with uncommitted changes, including untracked files.
+func (r Repo) DirtyPaths() ([]string, error) {
+	out, err := r.run("status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		e := entries[i]
+		if len(e) < 4 {
+			continue
+		}
+		paths = append(paths, e[3:])
+		// Renames and copies are followed by the original path.
+		if e[0] == 'R' || e[0] == 'C' {
+			i++
+		}
+	}
+	return paths, nil
+}
+
+func (r Repo) CurrentBranch() (string, error) {
+	out, err := r.run("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (r Repo) BranchExists(name string) bool {
+	_, err := r.run("rev-parse", "--verify", "--quiet", "refs/heads/"+name)
+	return err == nil
+}
+
+func (r Repo) CreateBranch(name string) error {
+	_, err := r.run("checkout", "-b", name)
+	return err
+}
+
+func (r Repo) Checkout(name string) error {
+	_, err := r.run("checkout", "-q", name)
+	return err
+}
+
+func (r Repo) DeleteBranch(name string) error {
+	_, err := r.run("branch", "-D", name)
+	return err
+}
+
+// Stash stashes all uncommitted changes, including untracked files. It
+// reports whether anything was stashed.
+func (r Repo) Stash(message string) (bool, error) {
+	before, _ := r.run("rev-parse", "--verify", "--quiet", "refs/stash")
+	if _, err := r.run("stash", "push", "--include-untracked", "-m", message); err != nil {
+		return false, err
+	}
+	after, _ := r.run("rev-parse", "--verify", "--quiet", "refs/stash")
+	return strings.TrimSpace(after) != "" && after != before, nil
+}
+
+func (r Repo) StashPop() error {
+	_, err := r.run("stash", "pop")
+	return err
+}
+
+// Diff returns the diff of the worktree against HEAD, or of the index when
+// staged is set. Paths limit the diff when given.
+func (r Repo) Diff(staged bool, paths ...string) (string, error) {
+	args := []string{"diff", "--no-color"}
+	if staged {
+		args = append(args, "--cached")
+	} else if r.hasHead() {
+		args = append(args, "HEAD")
+	}
+	args = append(args, "--")
+	args = append(args, paths...)
+	return r.run(args...)
+}
+
+// Show returns the log message and patch for rev.
+func (r Repo) Show(rev string) (string, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("invalid revision %q", rev)
+	}
+	return r.run("show", "--no-color", "--stat", "--patch", rev, "--")
+}
+
+func (r Repo) Add(paths ...string) error {
+	args := append([]string{"add", "-A", "--"}, paths...)
+	_, err := r.run(args...)
+	return err
+}
+
+// Commit commits the index and returns the short hash of the new commit.
+func (r Repo) Commit(message string) (string, error) {
+	if _, err := r.run("commit", "-q", "-m", message); err != nil {
+		return "", err
+	}
+	out, err := r.run("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (r Repo) hasHead() bool {
+	_, err := r.run("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
+
+// EnsureExcluded adds pattern to .git/info/exclude if it is not there yet.
+func (r Repo) EnsureExcluded(pattern string) error {
+	out, err := r.run("rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return err
+	}
+	path := strings.TrimSpace(out)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.Dir, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	prefix := ""
+	if len(b) > 0 && !bytes.HasSuffix(b, []byte("\n")) {
+		prefix = "\n"
+	}
+	_, err = f.WriteString(prefix + pattern + "\n")
+	return err
+}
+
+// BranchName turns a free-form topic into a minibrain/<slug> branch name.
+func BranchName(topic string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(topic) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 40 {
+			break
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "changes"
+	}
+	return "minibrain/" + slug
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initRepo(t *testing.T) Repo {
+	t.Helper()
+	if !Available() {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		if _, err := run(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestCommitAndDirtyPaths(t *testing.T) {
+	repo := initRepo(t)
+	if err := os.WriteFile(filepath.Join(repo.Dir, "a.txt"), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dirty, err := repo.DirtyPaths()
+	if err != nil || len(dirty) != 1 || dirty[0] != "a.txt" {
+		t.Fatalf("unexpected dirty paths: %v %v", dirty, err)
+	}
+	if err := repo.Add("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := repo.Commit("Add a.txt")
+	if err != nil || hash == "" {
+		t.Fatalf("commit failed: %q %v", hash, err)
+	}
+	if dirty, _ := repo.DirtyPaths(); len(dirty) != 0 {
+		t.Fatalf("expected clean worktree, got %v", dirty)
+	}
+	if err := os.WriteFile(filepath.Join(repo.Dir, "a.txt"), []byte("two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := repo.Diff(false)
+	if err != nil || !strings.Contains(diff, "+two") {
+		t.Fatalf("unexpected diff: %q %v", diff, err)
+	}
+}
+
+func TestEnsureExcluded(t *testing.T) {
+	repo := initRepo(t)
+	if err := repo.EnsureExcluded(".minibrain/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.EnsureExcluded(".minibrain/"); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(filepath.Join(repo.Dir, ".git", "info", "exclude"))
+	if strings.Count(string(b), ".minibrain/") != 1 {
+		t.Fatalf("expected a single exclude entry, got %q", b)
+	}
+	if err := os.MkdirAll(filepath.Join(repo.Dir, ".minibrain"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo.Dir, ".minibrain", "config.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if dirty, _ := repo.DirtyPaths(); len(dirty) != 0 {
+		t.Fatalf("expected .minibrain to be ignored, got %v", dirty)
+	}
+}
+
+func TestBranchName(t *testing.T) {
+	if got := BranchName("Add a /login page!"); got != "minibrain/add-a-login-page" {
+		t.Fatalf("unexpected branch name: %q", got)
+	}
+	if got := BranchName("???"); got != "minibrain/changes" {
+		t.Fatalf("unexpected branch name: %q", got)
+	}
+}
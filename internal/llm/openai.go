@@ -42,6 +42,7 @@ type responsesResponse struct {
 			Text string `json:"text"`
 		} `json:"content"`
 	} `json:"output"`
+	Usage *Usage `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
 		Type    string `json:"type"`
@@ -49,16 +50,14 @@ type responsesResponse struct {
 	} `json:"error"`
 }
 
-func CallOpenAI(ctx context.Context, model, developerMsg, userMsg string) (string, error) {
-	apiKey, err := loadAPIKey()
-	if err != nil {
-		return "", err
-	}
-	if model == "" {
-		model = "gpt-4.1"
-	}
+// Usage is the token accounting the API reports for one response.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
 
-	schema := []byte(`{
+var responseSchema = json.RawMessage(`{
   "type": "object",
   "additionalProperties": false,
   "properties": {
@@ -72,6 +71,17 @@ func CallOpenAI(ctx context.Context, model, developerMsg, userMsg string) (strin
       },
       "required": ["path", "diff"]
     }},
+    "edits": { "type": "array", "items": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "path": { "type": "string" },
+        "old_string": { "type": "string" },
+        "new_string": { "type": "string" },
+        "replace_all": { "type": "boolean" }
+      },
+      "required": ["path", "old_string", "new_string", "replace_all"]
+    }},
     "writes": { "type": "array", "items": {
       "type": "object",
       "additionalProperties": false,
@@ -84,143 +94,157 @@ func CallOpenAI(ctx context.Context, model, developerMsg, userMsg string) (strin
     "deletes": { "type": "array", "items": { "type": "string" } },
     "message": { "type": "string" }
   },
-  "required": ["read", "patches", "writes", "deletes", "message"]
+  "required": ["read", "patches", "edits", "writes", "deletes", "message"]
 }`)
 
-	payload := responsesRequest{
+func structuredFormat() *responseText {
+	return &responseText{
+		Format: &responseFormat{
+			Type:   "json_schema",
+			Name:   "minibrain_response",
+			Strict: true,
+			Schema: responseSchema,
+		},
+	}
+}
+
+// CallOpenAI asks for a response matching the minibrain JSON schema.
+func CallOpenAI(ctx context.Context, model, developerMsg, userMsg string) (string, error) {
+	out, _, err := CallOpenAIUsage(ctx, model, developerMsg, userMsg)
+	return out, err
+}
+
+// CallOpenAIUsage is CallOpenAI that also returns the reported token usage.
+func CallOpenAIUsage(ctx context.Context, model, developerMsg, userMsg string) (string, Usage, error) {
+	return callResponsesUsage(ctx, responsesRequest{
+		Model:        model,
+		Instructions: developerMsg,
+		Input:        userMsg,
+		Text:         structuredFormat(),
+	})
+}
+
+// CallOpenAIText asks for a plain-text response, for helper calls such as
+// commit messages and summaries that do not edit files.
+func CallOpenAIText(ctx context.Context, model, developerMsg, userMsg string) (string, error) {
+	return callResponses(ctx, responsesRequest{
+		Model:        model,
+		Instructions: developerMsg,
+		Input:        userMsg,
+	})
+}
+
+// CallOpenAIJSON asks for a response matching a caller-supplied JSON schema.
+func CallOpenAIJSON(ctx context.Context, model, developerMsg, userMsg, name string, schema json.RawMessage) (string, error) {
+	return callResponses(ctx, responsesRequest{
 		Model:        model,
 		Instructions: developerMsg,
 		Input:        userMsg,
 		Text: &responseText{
 			Format: &responseFormat{
 				Type:   "json_schema",
-				Name:   "minibrain_response",
+				Name:   name,
 				Strict: true,
 				Schema: schema,
 			},
 		},
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return "", err
-	}
+	})
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/responses", bytes.NewReader(body))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
+func callResponses(ctx context.Context, payload responsesRequest) (string, error) {
+	out, _, err := callResponsesUsage(ctx, payload)
+	return out, err
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func callResponsesUsage(ctx context.Context, payload responsesRequest) (string, Usage, error) {
+	resp, err := postResponses(ctx, payload)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", fmt.Errorf("openai error: %s", strings.TrimSpace(string(b)))
+		return "", Usage{}, fmt.Errorf("openai error: %s", strings.TrimSpace(string(b)))
 	}
+	return parseResponse(b)
+}
 
+func parseResponse(b []byte) (string, Usage, error) {
 	var out responsesResponse
 	if err := json.Unmarshal(b, &out); err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	if out.Error != nil {
-		return "", formatOpenAIError(out.Error.Code, out.Error.Type, out.Error.Message)
+		return "", Usage{}, formatOpenAIError(out.Error.Code, out.Error.Type, out.Error.Message)
+	}
+	var usage Usage
+	if out.Usage != nil {
+		usage = *out.Usage
 	}
 
 	for _, item := range out.Output {
 		for _, c := range item.Content {
 			if c.Type == "output_text" && strings.TrimSpace(c.Text) != "" {
-				return c.Text, nil
+				return c.Text, usage, nil
 			}
 		}
 	}
 
-	return "", errors.New("no output_text found in response")
+	return "", usage, errors.New("no output_text found in response")
 }
 
-func CallOpenAIStream(ctx context.Context, model, developerMsg, userMsg string, onDelta func(string)) (string, error) {
+func postResponses(ctx context.Context, payload responsesRequest) (*http.Response, error) {
 	apiKey, err := loadAPIKey()
 	if err != nil {
-		return "", err
-	}
-	if model == "" {
-		model = "gpt-4.1"
+		return nil, err
 	}
-
-	schema := []byte(`{
-  "type": "object",
-  "additionalProperties": false,
-  "properties": {
-    "read": { "type": "array", "items": { "type": "string" } },
-    "patches": { "type": "array", "items": {
-      "type": "object",
-      "additionalProperties": false,
-      "properties": {
-        "path": { "type": "string" },
-        "diff": { "type": "string" }
-      },
-      "required": ["path", "diff"]
-    }},
-    "writes": { "type": "array", "items": {
-      "type": "object",
-      "additionalProperties": false,
-      "properties": {
-        "path": { "type": "string" },
-        "content": { "type": "string" }
-      },
-      "required": ["path", "content"]
-    }},
-    "deletes": { "type": "array", "items": { "type": "string" } },
-    "message": { "type": "string" }
-  },
-  "required": ["read", "patches", "writes", "deletes", "message"]
-}`)
-
-	payload := responsesRequest{
-		Model:        model,
-		Instructions: developerMsg,
-		Input:        userMsg,
-		Stream:       true,
-		Text: &responseText{
-			Format: &responseFormat{
-				Type:   "json_schema",
-				Name:   "minibrain_response",
-				Strict: true,
-				Schema: schema,
-			},
-		},
+	if payload.Model == "" {
+		payload.Model = "gpt-4.1"
 	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/responses", bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	return http.DefaultClient.Do(req)
+}
+
+func CallOpenAIStream(ctx context.Context, model, developerMsg, userMsg string, onDelta func(string)) (string, error) {
+	out, _, err := CallOpenAIStreamUsage(ctx, model, developerMsg, userMsg, onDelta)
+	return out, err
+}
+
+// CallOpenAIStreamUsage is CallOpenAIStream that also returns the token
+// usage from the final response.completed event.
+func CallOpenAIStreamUsage(ctx context.Context, model, developerMsg, userMsg string, onDelta func(string)) (string, Usage, error) {
+	resp, err := postResponses(ctx, responsesRequest{
+		Model:        model,
+		Instructions: developerMsg,
+		Input:        userMsg,
+		Stream:       true,
+		Text:         structuredFormat(),
+	})
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("openai error: %s", strings.TrimSpace(string(b)))
+		return "", Usage{}, fmt.Errorf("openai error: %s", strings.TrimSpace(string(b)))
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
@@ -228,6 +252,7 @@ func CallOpenAIStream(ctx context.Context, model, developerMsg, userMsg string,
 	scanner.Buffer(buf, 1024*1024)
 
 	var out strings.Builder
+	var usage Usage
 	for scanner.Scan() {
 		line := scanner.Text()
 		if !strings.HasPrefix(line, "data: ") {
@@ -245,17 +270,32 @@ func CallOpenAIStream(ctx context.Context, model, developerMsg, userMsg string,
 			continue
 		}
 		if errMsg := streamError(payload); errMsg != "" {
-			return "", errors.New(errMsg)
+			return "", usage, errors.New(errMsg)
+		}
+		if u, ok := streamUsage([]byte(data)); ok {
+			usage = u
 		}
 		if delta := extractStreamDelta(payload); delta != "" {
 			out.WriteString(delta)
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return "", err
+		return "", usage, err
 	}
 
-	return out.String(), nil
+	return out.String(), usage, nil
+}
+
+func streamUsage(data []byte) (Usage, bool) {
+	var ev struct {
+		Response struct {
+			Usage *Usage `json:"usage"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(data, &ev); err != nil || ev.Response.Usage == nil {
+		return Usage{}, false
+	}
+	return *ev.Response.Usage, true
 }
 
 func streamError(payload map[string]any) string {
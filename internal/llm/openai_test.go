@@ -44,3 +44,20 @@ func TestLoadAPIKeyMissing(t *testing.T) {
 		t.Fatal("expected error for missing key")
 	}
 }
+
+func TestParseResponseUsage(t *testing.T) {
+	body := `{"output":[{"type":"message","content":[{"type":"output_text","text":"{}"}]}],"usage":{"input_tokens":12,"output_tokens":3,"total_tokens":15}}`
+	out, usage, err := parseResponse([]byte(body))
+	if err != nil || out != "{}" {
+		t.Fatalf("unexpected output %q %v", out, err)
+	}
+	if usage != (Usage{InputTokens: 12, OutputTokens: 3, TotalTokens: 15}) {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+	if u, ok := streamUsage([]byte(`{"type":"response.completed","response":{"usage":{"input_tokens":1,"output_tokens":2,"total_tokens":3}}}`)); !ok || u.TotalTokens != 3 {
+		t.Fatalf("unexpected stream usage %+v %v", u, ok)
+	}
+	if _, ok := streamUsage([]byte(`{"type":"response.output_text.delta","delta":"x"}`)); ok {
+		t.Fatal("expected no usage on a delta event")
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/chrishannah/minibrain/internal/vault"
 )
 
 type Config struct {
-	OpenAIAPIKey string `json:"openai_api_key"`
-	Model        string `json:"model"`
+	OpenAIAPIKey   string   `json:"openai_api_key"`
+	Model          string   `json:"model"`
+	AutoPromote    bool     `json:"auto_promote,omitempty"`
+	RedactPatterns []string `json:"redact_patterns,omitempty"`
 }
 
 func Load() (Config, error) {
@@ -17,7 +21,7 @@ func Load() (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
-	b, err := os.ReadFile(path)
+	b, err := vault.ReadFile(path)
 	if err != nil {
 		return Config{}, err
 	}
@@ -33,14 +37,14 @@ func Save(cfg Config) error {
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
 		return err
 	}
 	b, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, b, 0644)
+	return vault.WriteFile(path, b, 0600)
 }
 
 func Path() (string, error) {
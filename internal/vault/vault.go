@@ -0,0 +1,439 @@
+// Package vault encrypts brain files at rest with AES-GCM. Encryption is on
+// for a brain dir when it holds vault.json; the key comes from MINIBRAIN_KEY
+// or is derived from MINIBRAIN_PASSPHRASE with scrypt.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KeyEnv        = "MINIBRAIN_KEY"
+	PassphraseEnv = "MINIBRAIN_PASSPHRASE"
+
+	magic      = "MBVAULT1"
+	checkPlain = "minibrain"
+)
+
+var ErrLocked = errors.New("brain dir is encrypted; set " + KeyEnv + " or " + PassphraseEnv)
+
+type params struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt,omitempty"`
+	N       int    `json:"n,omitempty"`
+	R       int    `json:"r,omitempty"`
+	P       int    `json:"p,omitempty"`
+	Check   []byte `json:"check"`
+}
+
+type Vault struct {
+	BrainDir string
+	KDF      string
+	aead     cipher.AEAD
+}
+
+func ConfigPath(brainDir string) string {
+	return filepath.Join(brainDir, "vault.json")
+}
+
+func Enabled(brainDir string) bool {
+	_, err := os.Stat(ConfigPath(brainDir))
+	return err == nil
+}
+
+var (
+	opened sync.Map // brain dir -> *Vault
+	owners sync.Map // file dir -> brain dir ("" when not covered)
+)
+
+func reset() {
+	opened.Range(func(k, _ any) bool { opened.Delete(k); return true })
+	owners.Range(func(k, _ any) bool { owners.Delete(k); return true })
+}
+
+// Open unlocks the vault for brainDir. It returns nil when encryption is off.
+func Open(brainDir string) (*Vault, error) {
+	if v, ok := opened.Load(brainDir); ok {
+		return v.(*Vault), nil
+	}
+	b, err := os.ReadFile(ConfigPath(brainDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var p params
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("invalid vault.json: %w", err)
+	}
+	key, err := deriveKey(p)
+	if err != nil {
+		return nil, err
+	}
+	v, err := newVault(brainDir, p.KDF, key)
+	if err != nil {
+		return nil, err
+	}
+	if plain, err := v.Decrypt(p.Check); err != nil || string(plain) != checkPlain {
+		return nil, errors.New("wrong key or passphrase for the brain dir")
+	}
+	opened.Store(brainDir, v)
+	return v, nil
+}
+
+func newVault(brainDir, kdf string, key []byte) (*Vault, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{BrainDir: brainDir, KDF: kdf, aead: aead}, nil
+}
+
+func deriveKey(p params) ([]byte, error) {
+	switch p.KDF {
+	case "key":
+		raw := strings.TrimSpace(os.Getenv(KeyEnv))
+		if raw == "" {
+			return nil, ErrLocked
+		}
+		return decodeKey(raw)
+	case "scrypt":
+		pass := os.Getenv(PassphraseEnv)
+		if pass == "" {
+			return nil, ErrLocked
+		}
+		return scrypt.Key([]byte(pass), p.Salt, p.N, p.R, p.P, 32)
+	}
+	return nil, fmt.Errorf("unknown kdf %q in vault.json", p.KDF)
+}
+
+// decodeKey accepts a 32-byte key as hex or base64.
+func decodeKey(raw string) ([]byte, error) {
+	if b, err := hex.DecodeString(raw); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(raw); err == nil && len(b) == 32 {
+			return b, nil
+		}
+	}
+	return nil, errors.New(KeyEnv + " must be 32 bytes as hex or base64")
+}
+
+// Create turns encryption on for brainDir, using MINIBRAIN_KEY when set and
+// MINIBRAIN_PASSPHRASE otherwise. Existing files are not touched; see
+// EncryptAll.
+func Create(brainDir string) (*Vault, error) {
+	if Enabled(brainDir) {
+		return nil, errors.New("encryption is already enabled")
+	}
+	p := params{Version: 1}
+	switch {
+	case strings.TrimSpace(os.Getenv(KeyEnv)) != "":
+		p.KDF = "key"
+	case os.Getenv(PassphraseEnv) != "":
+		p.KDF = "scrypt"
+		p.Salt = make([]byte, 16)
+		if _, err := rand.Read(p.Salt); err != nil {
+			return nil, err
+		}
+		p.N, p.R, p.P = 1<<15, 8, 1
+	default:
+		return nil, errors.New("set " + KeyEnv + " or " + PassphraseEnv + " first")
+	}
+	key, err := deriveKey(p)
+	if err != nil {
+		return nil, err
+	}
+	v, err := newVault(brainDir, p.KDF, key)
+	if err != nil {
+		return nil, err
+	}
+	if p.Check, err = v.Encrypt([]byte(checkPlain)); err != nil {
+		return nil, err
+	}
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(brainDir, 0700); err != nil {
+		return nil, err
+	}
+	if err := WriteFile(ConfigPath(brainDir), b, 0600); err != nil {
+		return nil, err
+	}
+	reset()
+	return v, nil
+}
+
+func (v *Vault) Encrypt(plain []byte) ([]byte, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte(magic), nonce...)
+	return v.aead.Seal(out, nonce, plain, nil), nil
+}
+
+func (v *Vault) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, errors.New("not an encrypted file")
+	}
+	data = data[len(magic):]
+	n := v.aead.NonceSize()
+	if len(data) < n {
+		return nil, errors.New("encrypted file is truncated")
+	}
+	plain, err := v.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return nil, errors.New("cannot decrypt file: wrong key or corrupted data")
+	}
+	return plain, nil
+}
+
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(magic))
+}
+
+// Covers reports whether path is encrypted when brainDir has encryption on:
+// the user config and everything under cortex.
+func Covers(brainDir, path string) bool {
+	rel, err := filepath.Rel(brainDir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "config.json" || strings.HasPrefix(rel, "cortex"+string(filepath.Separator))
+}
+
+// For returns the vault that covers path, or nil when path is not a brain
+// file or its brain dir is not encrypted.
+func For(path string) (*Vault, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(abs)
+	brainDir, ok := owners.Load(dir)
+	if !ok {
+		brainDir = findBrainDir(dir)
+		owners.Store(dir, brainDir)
+	}
+	if brainDir == "" || !Covers(brainDir.(string), abs) {
+		return nil, nil
+	}
+	return Open(brainDir.(string))
+}
+
+func findBrainDir(dir string) string {
+	for i := 0; i < 6; i++ {
+		if Enabled(dir) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+// ReadFile reads path, decrypting it when it is encrypted. Plain files are
+// returned as they are, so turning encryption on never breaks reads.
+func ReadFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil || !IsEncrypted(b) {
+		return b, err
+	}
+	v, err := For(path)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%s is encrypted but not inside an encrypted brain dir", filepath.Base(path))
+	}
+	return v.Decrypt(b)
+}
+
+// WriteFile replaces path through a temp file and rename, encrypting data
+// first when path is covered by a vault. The mode is never loosened.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	v, err := For(path)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		if data, err = v.Encrypt(data); err != nil {
+			return err
+		}
+	}
+	return writeAtomic(path, data, perm)
+}
+
+func writeAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	cleanup := func() { _ = os.Remove(name) }
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm &= info.Mode().Perm()
+	}
+	if err := os.Chmod(name, perm); err != nil {
+		cleanup()
+		return err
+	}
+	if err := os.Rename(name, path); err != nil {
+		cleanup()
+		return err
+	}
+	return nil
+}
+
+// EncryptAll encrypts every covered file in brainDir that is still plain and
+// returns how many were converted.
+func EncryptAll(brainDir string) (int, error) {
+	v, err := Open(brainDir)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		return 0, errors.New("encryption is not enabled")
+	}
+	return convert(brainDir, func(b []byte) ([]byte, bool, error) {
+		if IsEncrypted(b) {
+			return nil, false, nil
+		}
+		out, err := v.Encrypt(b)
+		return out, true, err
+	})
+}
+
+// Disable decrypts every covered file and turns encryption off.
+func Disable(brainDir string) (int, error) {
+	v, err := Open(brainDir)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		return 0, errors.New("encryption is not enabled")
+	}
+	n, err := convert(brainDir, func(b []byte) ([]byte, bool, error) {
+		if !IsEncrypted(b) {
+			return nil, false, nil
+		}
+		out, err := v.Decrypt(b)
+		return out, true, err
+	})
+	if err != nil {
+		return n, err
+	}
+	if err := os.Remove(ConfigPath(brainDir)); err != nil {
+		return n, err
+	}
+	reset()
+	return n, nil
+}
+
+// Files lists the covered files in brainDir, skipping lock and temp files.
+func Files(brainDir string) ([]string, error) {
+	var out []string
+	if _, err := os.Stat(filepath.Join(brainDir, "config.json")); err == nil {
+		out = append(out, filepath.Join(brainDir, "config.json"))
+	}
+	err := filepath.WalkDir(filepath.Join(brainDir, "cortex"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		name := d.Name()
+		if d.Type().IsRegular() && !(strings.HasPrefix(name, ".") && (strings.HasSuffix(name, ".lock") || strings.Contains(name, ".tmp-"))) {
+			out = append(out, path)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func convert(brainDir string, fn func([]byte) ([]byte, bool, error)) (int, error) {
+	files, err := Files(brainDir)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, path := range files {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return n, err
+		}
+		out, changed, err := fn(b)
+		if err != nil {
+			return n, fmt.Errorf("%s: %w", path, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := writeAtomic(path, out, 0600); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Status reports whether encryption is on and how many covered files are
+// encrypted.
+func Status(brainDir string) (enabled bool, encrypted, total int, err error) {
+	files, err := Files(brainDir)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	for _, path := range files {
+		b, err := os.ReadFile(path)
+		if err == nil && IsEncrypted(b) {
+			encrypted++
+		}
+	}
+	return Enabled(brainDir), encrypted, len(files), nil
+}
@@ -0,0 +1,95 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	brainDir := t.TempDir()
+	t.Setenv(KeyEnv, "")
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	neo := filepath.Join(brainDir, "cortex", "NEO.md")
+	soul := filepath.Join(brainDir, "SOUL.md")
+	if err := WriteFile(neo, []byte("- plain before\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Create(brainDir)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if v.KDF != "scrypt" {
+		t.Fatalf("expected scrypt, got %s", v.KDF)
+	}
+	if n, err := EncryptAll(brainDir); err != nil || n != 1 {
+		t.Fatalf("encrypt all: %d %v", n, err)
+	}
+	if err := WriteFile(neo, []byte("- secret fact\n"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := WriteFile(soul, []byte("# SOUL\n"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	raw, _ := os.ReadFile(neo)
+	if !IsEncrypted(raw) || strings.Contains(string(raw), "secret fact") {
+		t.Fatalf("expected ciphertext on disk, got %q", raw)
+	}
+	if raw, _ := os.ReadFile(soul); IsEncrypted(raw) {
+		t.Fatal("files outside cortex should stay plain")
+	}
+	if b, err := ReadFile(neo); err != nil || string(b) != "- secret fact\n" {
+		t.Fatalf("read: %q %v", b, err)
+	}
+	if info, _ := os.Stat(neo); info.Mode().Perm() != 0600 {
+		t.Fatalf("expected 0600, got %v", info.Mode())
+	}
+
+	reset()
+	t.Setenv(PassphraseEnv, "wrong")
+	if _, err := ReadFile(neo); err == nil {
+		t.Fatal("expected wrong passphrase to fail")
+	}
+	reset()
+	t.Setenv(PassphraseEnv, "")
+	if _, err := ReadFile(neo); err != ErrLocked {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+
+	reset()
+	t.Setenv(PassphraseEnv, "correct horse battery staple")
+	if n, err := Disable(brainDir); err != nil || n != 1 {
+		t.Fatalf("disable: %d %v", n, err)
+	}
+	if raw, _ := os.ReadFile(neo); string(raw) != "- secret fact\n" || Enabled(brainDir) {
+		t.Fatalf("expected plain file after disable, got %q", raw)
+	}
+}
+
+func TestKeyFromEnv(t *testing.T) {
+	brainDir := t.TempDir()
+	t.Setenv(PassphraseEnv, "")
+	t.Setenv(KeyEnv, "")
+	if _, err := Create(brainDir); err == nil {
+		t.Fatal("expected an error without a key")
+	}
+	t.Setenv(KeyEnv, strings.Repeat("ab", 32))
+	v, err := Create(brainDir)
+	if err != nil || v.KDF != "key" {
+		t.Fatalf("create: %v", err)
+	}
+	cfg := filepath.Join(brainDir, "config.json")
+	if err := WriteFile(cfg, []byte(`{"openai_api_key":"sk-test"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := os.ReadFile(cfg); !IsEncrypted(raw) {
+		t.Fatal("expected config.json to be encrypted")
+	}
+	t.Setenv(KeyEnv, "short")
+	reset()
+	if _, err := Open(brainDir); err == nil {
+		t.Fatal("expected invalid key error")
+	}
+}